@@ -0,0 +1,40 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+const (
+	// ConditionTypeReady indicates if a ShortLink or Redirect is served
+	ConditionTypeReady = "Ready"
+
+	// ConditionTypeValid indicates if the spec of a ShortLink or Redirect is valid
+	ConditionTypeValid = "Valid"
+
+	// ConditionTypeExpired indicates if a ShortLink passed its expiry date
+	ConditionTypeExpired = "Expired"
+
+	// ConditionTypeTargetReachable indicates if the last health check of the target of a ShortLink succeeded
+	ConditionTypeTargetReachable = "TargetReachable"
+
+	// ConditionTypeIngressCreated indicates if the Ingress of a Redirect was created
+	ConditionTypeIngressCreated = "IngressCreated"
+
+	// ConditionTypeHTTPRouteCreated indicates if the HTTPRoute of a Redirect was created
+	ConditionTypeHTTPRouteCreated = "HTTPRouteCreated"
+
+	// ConditionTypeCertificateReady indicates if the cert-manager Certificate of a Redirect is issued and not expired
+	ConditionTypeCertificateReady = "CertificateReady"
+)
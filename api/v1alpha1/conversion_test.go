@@ -0,0 +1,216 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1beta1"
+	fuzz "github.com/google/gofuzz"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	httpCodes = []int{300, 301, 302, 303, 304, 305, 307, 308}
+
+	// htmlCodeAnnotationValues are the values of HTMLCodeAnnotation the v1alpha1 fuzzer sets, including invalid ones
+	htmlCodeAnnotationValues = []string{"301", "308", "0", "", "abc", "+301"}
+)
+
+// newFuzzer returns a fuzzer creating objects as the API server stores them: times have a precision of seconds,
+// and enums only hold the values allowed by the CRD
+func newFuzzer(seed int64) *fuzz.Fuzzer {
+	return fuzz.NewWithSeed(seed).NilChance(0.2).NumElements(0, 3).Funcs(
+		func(typeMeta *metav1.TypeMeta, c fuzz.Continue) {
+			// The conversion webhook sets the TypeMeta, not the Convert functions
+			*typeMeta = metav1.TypeMeta{}
+		},
+		func(t *metav1.Time, c fuzz.Continue) {
+			*t = metav1.Unix(c.Int63n(1<<33), 0)
+		},
+		func(meta *metav1.ObjectMeta, c fuzz.Continue) {
+			c.Fuzz(&meta.Name)
+			c.Fuzz(&meta.Namespace)
+			c.Fuzz(&meta.Generation)
+			c.Fuzz(&meta.ResourceVersion)
+			c.Fuzz(&meta.Labels)
+			c.Fuzz(&meta.Annotations)
+			c.Fuzz(&meta.Finalizers)
+			c.Fuzz(&meta.CreationTimestamp)
+			c.Fuzz(&meta.DeletionTimestamp)
+		},
+		func(spec *ShortLinkSpec, c fuzz.Continue) {
+			c.FuzzNoCustom(spec)
+			spec.Code = append([]int{200}, httpCodes...)[c.Intn(len(httpCodes)+1)]
+		},
+		func(status *ShortLinkStatus, c fuzz.Continue) {
+			c.FuzzNoCustom(status)
+
+			// The controller writes LastModified as RFC 3339 timestamp in UTC
+			status.LastModified = ""
+			if c.RandBool() {
+				status.LastModified = time.Unix(c.Int63n(1<<33), 0).UTC().Format(time.RFC3339)
+			}
+		},
+		func(shortlink *ShortLink, c fuzz.Continue) {
+			c.FuzzNoCustom(shortlink)
+
+			if c.RandBool() {
+				if shortlink.Annotations == nil {
+					shortlink.Annotations = make(map[string]string)
+				}
+
+				shortlink.Annotations[HTMLCodeAnnotation] = htmlCodeAnnotationValues[c.Intn(len(htmlCodeAnnotationValues))]
+			}
+		},
+		func(spec *v1beta1.ShortLinkSpec, c fuzz.Continue) {
+			c.FuzzNoCustom(spec)
+
+			spec.Type = v1beta1.ShortLinkTypeHTTP
+			spec.Code = httpCodes[c.Intn(len(httpCodes))]
+
+			// The code of the HTML type is optional
+			if c.RandBool() {
+				spec.Type = v1beta1.ShortLinkTypeHTML
+				if c.RandBool() {
+					spec.Code = 0
+				}
+			}
+		},
+		func(shortlink *v1beta1.ShortLink, c fuzz.Continue) {
+			c.FuzzNoCustom(shortlink)
+
+			// HTMLCodeAnnotation is reserved for the conversion and never set on the hub version
+			delete(shortlink.Annotations, HTMLCodeAnnotation)
+		},
+	)
+}
+
+// fuzzSeeds are run by go test, go test -fuzz generates more
+var fuzzSeeds = []int64{0, 1, 2, 3, 5, 8, 13, 21, 34, 55, 89, 144, 233, 377, 610, 987}
+
+func FuzzShortLinkRoundTrip(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		original := &ShortLink{}
+		newFuzzer(seed).Fuzz(original)
+
+		hub := &v1beta1.ShortLink{}
+		if err := original.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("ConvertTo() failed: %v", err)
+		}
+
+		converted := &ShortLink{}
+		if err := converted.ConvertFrom(hub); err != nil {
+			t.Fatalf("ConvertFrom() failed: %v", err)
+		}
+
+		if !equality.Semantic.DeepEqual(original, converted) {
+			t.Errorf("v1alpha1 -> v1beta1 -> v1alpha1 isn't lossless:\n%#v\n%#v", original, converted)
+		}
+	})
+}
+
+func FuzzShortLinkHubRoundTrip(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		original := &v1beta1.ShortLink{}
+		newFuzzer(seed).Fuzz(original)
+
+		spoke := &ShortLink{}
+		if err := spoke.ConvertFrom(original.DeepCopy()); err != nil {
+			t.Fatalf("ConvertFrom() failed: %v", err)
+		}
+
+		converted := &v1beta1.ShortLink{}
+		if err := spoke.ConvertTo(converted); err != nil {
+			t.Fatalf("ConvertTo() failed: %v", err)
+		}
+
+		if !equality.Semantic.DeepEqual(original, converted) {
+			t.Errorf("v1beta1 -> v1alpha1 -> v1beta1 isn't lossless:\n%#v\n%#v", original, converted)
+		}
+	})
+}
+
+func FuzzRedirectRoundTrip(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		original := &Redirect{}
+		newFuzzer(seed).Fuzz(original)
+
+		hub := &v1beta1.Redirect{}
+		if err := original.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("ConvertTo() failed: %v", err)
+		}
+
+		converted := &Redirect{}
+		if err := converted.ConvertFrom(hub); err != nil {
+			t.Fatalf("ConvertFrom() failed: %v", err)
+		}
+
+		if !equality.Semantic.DeepEqual(original, converted) {
+			t.Errorf("v1alpha1 -> v1beta1 -> v1alpha1 isn't lossless:\n%#v\n%#v", original, converted)
+		}
+	})
+}
+
+func FuzzRedirectHubRoundTrip(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		original := &v1beta1.Redirect{}
+		newFuzzer(seed).Fuzz(original)
+
+		spoke := &Redirect{}
+		if err := spoke.ConvertFrom(original.DeepCopy()); err != nil {
+			t.Fatalf("ConvertFrom() failed: %v", err)
+		}
+
+		converted := &v1beta1.Redirect{}
+		if err := spoke.ConvertTo(converted); err != nil {
+			t.Fatalf("ConvertTo() failed: %v", err)
+		}
+
+		if !equality.Semantic.DeepEqual(original, converted) {
+			t.Errorf("v1beta1 -> v1alpha1 -> v1beta1 isn't lossless:\n%#v\n%#v", original, converted)
+		}
+	})
+}
+
+func TestHTMLCodeRoundTrip(t *testing.T) {
+	original := &v1beta1.ShortLink{Spec: v1beta1.ShortLinkSpec{Type: v1beta1.ShortLinkTypeHTML, Code: 301}}
+
+	spoke := &ShortLink{}
+	if err := spoke.ConvertFrom(original.DeepCopy()); err != nil {
+		t.Fatalf("ConvertFrom() failed: %v", err)
+	}
+
+	if spoke.Spec.Code != 200 || spoke.Annotations[HTMLCodeAnnotation] != "301" {
+		t.Errorf("got code %d and annotation %q, want 200 and %q", spoke.Spec.Code, spoke.Annotations[HTMLCodeAnnotation], "301")
+	}
+
+	converted := &v1beta1.ShortLink{}
+	if err := spoke.ConvertTo(converted); err != nil {
+		t.Fatalf("ConvertTo() failed: %v", err)
+	}
+
+	if converted.Spec.Type != v1beta1.ShortLinkTypeHTML || converted.Spec.Code != 301 {
+		t.Errorf("got type %s with code %d, want HTML with 301", converted.Spec.Type, converted.Spec.Code)
+	}
+
+	if _, ok := converted.Annotations[HTMLCodeAnnotation]; ok {
+		t.Errorf("annotation %s was not removed from the hub version", HTMLCodeAnnotation)
+	}
+}
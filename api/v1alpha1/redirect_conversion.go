@@ -0,0 +1,118 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/cedi/urlshortener/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts this Redirect to the v1beta1 hub version
+func (src *Redirect) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Redirect)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = v1beta1.RedirectSpec{
+		Source:           src.Spec.Source,
+		Sources:          src.Spec.Sources,
+		Target:           src.Spec.Target,
+		Code:             src.Spec.Code,
+		IngressClassName: src.Spec.IngressClassName,
+		Backend:          src.Spec.Backend,
+		Native:           src.Spec.Native,
+		PreservePath:     src.Spec.PreservePath,
+		TLS: v1beta1.TLSSpec{
+			Enable:      src.Spec.TLS.Enable,
+			Annotations: src.Spec.TLS.Annotations,
+		},
+	}
+
+	for _, rule := range src.Spec.Rules {
+		dst.Spec.Rules = append(dst.Spec.Rules, v1beta1.RedirectRule(rule))
+	}
+
+	for _, parentRef := range src.Spec.ParentRefs {
+		dst.Spec.ParentRefs = append(dst.Spec.ParentRefs, v1beta1.GatewayReference(parentRef))
+	}
+
+	if src.Spec.TLS.Issuer != nil {
+		issuer := v1beta1.IssuerReference(*src.Spec.TLS.Issuer)
+		dst.Spec.TLS.Issuer = &issuer
+	}
+
+	dst.Status = v1beta1.RedirectStatus{
+		Target:              src.Status.Target,
+		IngressNames:        src.Status.IngressName,
+		Count:               src.Status.Count,
+		HTTPRouteName:       src.Status.HTTPRouteName,
+		CertificateName:     src.Status.CertificateName,
+		CertificateNotAfter: src.Status.CertificateNotAfter,
+		ObservedGeneration:  src.Status.ObservedGeneration,
+		Conditions:          src.Status.Conditions,
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the v1beta1 hub version to this Redirect
+func (dst *Redirect) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Redirect)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = RedirectSpec{
+		Source:           src.Spec.Source,
+		Sources:          src.Spec.Sources,
+		Target:           src.Spec.Target,
+		Code:             src.Spec.Code,
+		IngressClassName: src.Spec.IngressClassName,
+		Backend:          src.Spec.Backend,
+		Native:           src.Spec.Native,
+		PreservePath:     src.Spec.PreservePath,
+		TLS: TLSSpec{
+			Enable:      src.Spec.TLS.Enable,
+			Annotations: src.Spec.TLS.Annotations,
+		},
+	}
+
+	for _, rule := range src.Spec.Rules {
+		dst.Spec.Rules = append(dst.Spec.Rules, RedirectRule(rule))
+	}
+
+	for _, parentRef := range src.Spec.ParentRefs {
+		dst.Spec.ParentRefs = append(dst.Spec.ParentRefs, GatewayReference(parentRef))
+	}
+
+	if src.Spec.TLS.Issuer != nil {
+		issuer := IssuerReference(*src.Spec.TLS.Issuer)
+		dst.Spec.TLS.Issuer = &issuer
+	}
+
+	dst.Status = RedirectStatus{
+		Target:              src.Status.Target,
+		IngressName:         src.Status.IngressNames,
+		Count:               src.Status.Count,
+		HTTPRouteName:       src.Status.HTTPRouteName,
+		CertificateName:     src.Status.CertificateName,
+		CertificateNotAfter: src.Status.CertificateNotAfter,
+		ObservedGeneration:  src.Status.ObservedGeneration,
+		Conditions:          src.Status.Conditions,
+	}
+
+	return nil
+}
@@ -30,10 +30,19 @@ type RedirectSpec struct {
 	// +kubebuilder:validation:Required
 	Source string `json:"source"`
 
-	// Target is the destination URL to which the redirection happen
+	// Sources are additional source hosts which are redirected like Source
+	// +kubebuilder:validation:Optional
+	Sources []string `json:"sources,omitempty"`
+
+	// Target is the destination URL to which the redirection happen.
+	// Requests not matching any of the Rules are redirected to Target
 	// +kubebuilder:validation:Required
 	Target string `json:"target"`
 
+	// Rules redirect paths of the source hosts to individual targets. The first matching rule applies
+	// +kubebuilder:validation:Optional
+	Rules []RedirectRule `json:"rules,omitempty"`
+
 	// Code is the URL Code used for the redirection. Default 308
 	// +kubebuilder:validation:Enum=300;301;302;303;304;305;307;308
 	// +kubebuilder:default:=308
@@ -43,9 +52,78 @@ type RedirectSpec struct {
 	// +kubebuilder:default:={enable: false}
 	TLS TLSSpec `json:"tls,omitempty"`
 
-	// IngressClassName makes it possible to override the ingress-class
+	// IngressClassName makes it possible to override the ingress-class. It also selects how the redirect is rendered for the ingress controller
 	// +kubebuilder:default:=nginx
 	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Backend selects the resource implementing the redirect.
+	// Defaults to the --default-redirect-backend of the urlshortener
+	// +kubebuilder:validation:Enum=Ingress;HTTPRoute
+	// +kubebuilder:validation:Optional
+	Backend string `json:"backend,omitempty"`
+
+	// ParentRefs are the Gateways the HTTPRoute is attached to when using the HTTPRoute backend.
+	// Defaults to the --gateway of the urlshortener
+	// +kubebuilder:validation:Optional
+	ParentRefs []GatewayReference `json:"parentRefs,omitempty"`
+
+	// Native routes the source host to the urlshortener, which performs the redirect itself instead of the
+	// ingress controller or Gateway. Invocations of native redirects are counted like shortlinks
+	// +kubebuilder:validation:Optional
+	Native bool `json:"native,omitempty"`
+
+	// PreservePath appends the path and query of the request to Target.
+	// Targets without a scheme always keep the path and query
+	// +kubebuilder:validation:Optional
+	PreservePath bool `json:"preservePath,omitempty"`
+}
+
+const (
+	// RedirectBackendIngress implements a Redirect using a networking/v1 Ingress
+	RedirectBackendIngress = "Ingress"
+
+	// RedirectBackendHTTPRoute implements a Redirect using a Gateway API HTTPRoute
+	RedirectBackendHTTPRoute = "HTTPRoute"
+)
+
+// RedirectRule redirects requests to a path of the source hosts
+type RedirectRule struct {
+	// Path is the path prefix the rule applies to, e.g. /blog/. The rest of the path and the query
+	// of the request are appended to Target. Exactly one of Path and Regex must be set
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path,omitempty"`
+
+	// Regex is a regular expression matched against the path of the request, e.g. ^/blog/(\d+)$.
+	// It must start with ^/ and Target can reference its capture groups as $1 to $9
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^\^/`
+	Regex string `json:"regex,omitempty"`
+
+	// Target is the destination URL of requests matching the rule
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Target string `json:"target"`
+
+	// Code is the URL Code used for the redirection. Defaults to the Code of the Redirect
+	// +kubebuilder:validation:Enum=300;301;302;303;304;305;307;308
+	// +kubebuilder:validation:Optional
+	Code int `json:"code,omitempty"`
+}
+
+// GatewayReference references a Gateway API Gateway
+type GatewayReference struct {
+	// Name is the name of the Gateway
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Gateway. Defaults to the namespace of the Redirect
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName is the name of the listener of the Gateway to attach to
+	// +kubebuilder:validation:Optional
+	SectionName string `json:"sectionName,omitempty"`
 }
 
 // TLSSpec holds the TLS configuration used
@@ -53,21 +131,72 @@ type TLSSpec struct {
 	// +kubebuilder:default:=false
 	Enable      bool              `json:"enable,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Issuer requests a certificate for all source hosts from this cert-manager issuer.
+	// Requires the urlshortener to run with --enable-cert-manager. Replaces the cert-manager annotations in Annotations
+	// +kubebuilder:validation:Optional
+	Issuer *IssuerReference `json:"issuer,omitempty"`
+}
+
+// IssuerReference references a cert-manager Issuer or ClusterIssuer
+type IssuerReference struct {
+	// Name is the name of the issuer
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Kind is the kind of the issuer, Issuer or ClusterIssuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +kubebuilder:default:=ClusterIssuer
+	Kind string `json:"kind,omitempty"`
+
+	// Group is the API group of the issuer. Defaults to cert-manager.io, external issuers use their own group
+	// +kubebuilder:validation:Optional
+	Group string `json:"group,omitempty"`
 }
 
 // RedirectStatus defines the observed state of Redirect
 type RedirectStatus struct {
 	Target      string   `json:"target,omitempty"`
 	IngressName []string `json:"ingressNames,omitempty"`
+
+	// Count represents how often this Redirect has been called. Only native redirects are counted
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Count int `json:"count,omitempty"`
+
+	// HTTPRouteName is the name of the HTTPRoute implementing the Redirect when using the HTTPRoute backend
+	// +kubebuilder:validation:Optional
+	HTTPRouteName string `json:"httpRouteName,omitempty"`
+
+	// CertificateName is the name of the cert-manager Certificate of the source hosts
+	// +kubebuilder:validation:Optional
+	CertificateName string `json:"certificateName,omitempty"`
+
+	// CertificateNotAfter is the time the current certificate of the source hosts expires
+	// +kubebuilder:validation:Optional
+	CertificateNotAfter *metav1.Time `json:"certificateNotAfter,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the status was computed for
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of the Redirect
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // Redirect is the Schema for the redirects API
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 // +kubebuilder:resource:scope=Namespaced
 // +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source`
 // +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.target`
 // +kubebuilder:printcolumn:name="Code",type=string,JSONPath=`.spec.code`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Invoked",type=string,JSONPath=`.status.count`,priority=1
 type Redirect struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -92,3 +221,16 @@ func init() {
 func (s *ShortLink) IsOwnedBy(username string) bool {
 	return s.Spec.Owner == username || slices.Contains(s.Spec.CoOwners, username)
 }
+
+// Hosts returns Source and all additional Sources of the Redirect, without duplicates
+func (r *Redirect) Hosts() []string {
+	hosts := []string{r.Spec.Source}
+
+	for _, source := range r.Spec.Sources {
+		if !slices.Contains(hosts, source) {
+			hosts = append(hosts, source)
+		}
+	}
+
+	return hosts
+}
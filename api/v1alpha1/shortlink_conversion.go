@@ -0,0 +1,166 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// HTMLCodeAnnotation preserves the Code of v1beta1 ShortLinks of the HTML type, as v1alpha1 uses the Code 200
+// to select the HTML behaviour
+const HTMLCodeAnnotation = "urlshortener.cedi.dev/html-code"
+
+// ConvertTo converts this ShortLink to the v1beta1 hub version
+func (src *ShortLink) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.ShortLink)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = v1beta1.ShortLinkSpec{
+		Owner:                src.Spec.Owner,
+		CoOwners:             src.Spec.CoOwners,
+		Target:               src.Spec.Target,
+		Slug:                 src.Spec.Slug,
+		Aliases:              src.Spec.Aliases,
+		Type:                 v1beta1.ShortLinkTypeHTTP,
+		RedirectAfterSeconds: src.Spec.RedirectAfter,
+		Code:                 src.Spec.Code,
+		Description:          src.Spec.Description,
+		Tags:                 src.Spec.Tags,
+		DisableHealthCheck:   src.Spec.DisableHealthCheck,
+		ExpiresAt:            src.Spec.ExpiresAt,
+		QueryParameters:      src.Spec.QueryParameters,
+		QueryParameterPolicy: src.Spec.QueryParameterPolicy,
+	}
+
+	if src.Spec.Code == 200 {
+		dst.Spec.Type = v1beta1.ShortLinkTypeHTML
+		dst.Spec.Code = 0
+
+		// The annotation is only consumed if it holds a code, as ConvertFrom restores it from the code.
+		// Otherwise it is kept, so that converting back doesn't lose it
+		value := dst.Annotations[HTMLCodeAnnotation]
+		if code, err := strconv.Atoi(value); err == nil && code != 0 && strconv.Itoa(code) == value {
+			dst.Spec.Code = code
+
+			delete(dst.Annotations, HTMLCodeAnnotation)
+			if len(dst.Annotations) == 0 {
+				dst.Annotations = nil
+			}
+		}
+	}
+
+	dst.Status = v1beta1.ShortLinkStatus{
+		Count:              src.Status.Count,
+		AliasCounts:        src.Status.AliasCounts,
+		CrawlerCounts:      src.Status.CrawlerCounts,
+		ChangedBy:          src.Status.ChangedBy,
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         src.Status.Conditions,
+	}
+
+	if lastModified, err := time.Parse(time.RFC3339, src.Status.LastModified); err == nil {
+		dst.Status.LastModified = &metav1.Time{Time: lastModified}
+	}
+
+	if src.Status.HealthCheck != nil {
+		healthCheck := v1beta1.HealthCheckStatus(*src.Status.HealthCheck)
+		dst.Status.HealthCheck = &healthCheck
+	}
+
+	if src.Spec.OpenGraph != nil {
+		openGraph := v1beta1.OpenGraphSpec(*src.Spec.OpenGraph)
+		dst.Spec.OpenGraph = &openGraph
+	}
+
+	if src.Status.OpenGraph != nil {
+		openGraph := v1beta1.OpenGraphStatus(*src.Status.OpenGraph)
+		dst.Status.OpenGraph = &openGraph
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the v1beta1 hub version to this ShortLink
+func (dst *ShortLink) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.ShortLink)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = ShortLinkSpec{
+		Owner:                src.Spec.Owner,
+		CoOwners:             src.Spec.CoOwners,
+		Target:               src.Spec.Target,
+		Slug:                 src.Spec.Slug,
+		Aliases:              src.Spec.Aliases,
+		RedirectAfter:        src.Spec.RedirectAfterSeconds,
+		Code:                 src.Spec.Code,
+		Description:          src.Spec.Description,
+		Tags:                 src.Spec.Tags,
+		DisableHealthCheck:   src.Spec.DisableHealthCheck,
+		ExpiresAt:            src.Spec.ExpiresAt,
+		QueryParameters:      src.Spec.QueryParameters,
+		QueryParameterPolicy: src.Spec.QueryParameterPolicy,
+	}
+
+	if src.Spec.Type == v1beta1.ShortLinkTypeHTML {
+		dst.Spec.Code = 200
+
+		if src.Spec.Code != 0 {
+			if dst.Annotations == nil {
+				dst.Annotations = make(map[string]string)
+			}
+
+			dst.Annotations[HTMLCodeAnnotation] = strconv.Itoa(src.Spec.Code)
+		}
+	}
+
+	dst.Status = ShortLinkStatus{
+		Count:              src.Status.Count,
+		AliasCounts:        src.Status.AliasCounts,
+		CrawlerCounts:      src.Status.CrawlerCounts,
+		ChangedBy:          src.Status.ChangedBy,
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         src.Status.Conditions,
+	}
+
+	if src.Status.LastModified != nil {
+		dst.Status.LastModified = src.Status.LastModified.UTC().Format(time.RFC3339)
+	}
+
+	if src.Status.HealthCheck != nil {
+		healthCheck := HealthCheckStatus(*src.Status.HealthCheck)
+		dst.Status.HealthCheck = &healthCheck
+	}
+
+	if src.Spec.OpenGraph != nil {
+		openGraph := OpenGraphSpec(*src.Spec.OpenGraph)
+		dst.Spec.OpenGraph = &openGraph
+	}
+
+	if src.Status.OpenGraph != nil {
+		openGraph := OpenGraphStatus(*src.Status.OpenGraph)
+		dst.Status.OpenGraph = &openGraph
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RedirectSpec defines the desired state of Redirect
+type RedirectSpec struct {
+	// Source is the source URL from which the redirection happens
+	// +kubebuilder:validation:Required
+	Source string `json:"source"`
+
+	// Sources are additional source hosts which are redirected like Source
+	// +kubebuilder:validation:Optional
+	Sources []string `json:"sources,omitempty"`
+
+	// Target is the destination URL to which the redirection happen.
+	// Requests not matching any of the Rules are redirected to Target
+	// +kubebuilder:validation:Required
+	Target string `json:"target"`
+
+	// Rules redirect paths of the source hosts to individual targets. The first matching rule applies
+	// +kubebuilder:validation:Optional
+	Rules []RedirectRule `json:"rules,omitempty"`
+
+	// Code is the URL Code used for the redirection. Default 308
+	// +kubebuilder:validation:Enum=300;301;302;303;304;305;307;308
+	// +kubebuilder:default:=308
+	Code int `json:"code,omitempty"`
+
+	// TLS configure if you want to enable TLS
+	// +kubebuilder:default:={enable: false}
+	TLS TLSSpec `json:"tls,omitempty"`
+
+	// IngressClassName makes it possible to override the ingress-class. It also selects how the redirect is rendered for the ingress controller
+	// +kubebuilder:default:=nginx
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Backend selects the resource implementing the redirect.
+	// Defaults to the --default-redirect-backend of the urlshortener
+	// +kubebuilder:validation:Enum=Ingress;HTTPRoute
+	// +kubebuilder:validation:Optional
+	Backend string `json:"backend,omitempty"`
+
+	// ParentRefs are the Gateways the HTTPRoute is attached to when using the HTTPRoute backend.
+	// Defaults to the --gateway of the urlshortener
+	// +kubebuilder:validation:Optional
+	ParentRefs []GatewayReference `json:"parentRefs,omitempty"`
+
+	// Native routes the source host to the urlshortener, which performs the redirect itself instead of the
+	// ingress controller or Gateway. Invocations of native redirects are counted like shortlinks
+	// +kubebuilder:validation:Optional
+	Native bool `json:"native,omitempty"`
+
+	// PreservePath appends the path and query of the request to Target.
+	// Targets without a scheme always keep the path and query
+	// +kubebuilder:validation:Optional
+	PreservePath bool `json:"preservePath,omitempty"`
+}
+
+const (
+	// RedirectBackendIngress implements a Redirect using a networking/v1 Ingress
+	RedirectBackendIngress = "Ingress"
+
+	// RedirectBackendHTTPRoute implements a Redirect using a Gateway API HTTPRoute
+	RedirectBackendHTTPRoute = "HTTPRoute"
+)
+
+// RedirectRule redirects requests to a path of the source hosts
+type RedirectRule struct {
+	// Path is the path prefix the rule applies to, e.g. /blog/. The rest of the path and the query
+	// of the request are appended to Target. Exactly one of Path and Regex must be set
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path,omitempty"`
+
+	// Regex is a regular expression matched against the path of the request, e.g. ^/blog/(\d+)$.
+	// It must start with ^/ and Target can reference its capture groups as $1 to $9
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^\^/`
+	Regex string `json:"regex,omitempty"`
+
+	// Target is the destination URL of requests matching the rule
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Target string `json:"target"`
+
+	// Code is the URL Code used for the redirection. Defaults to the Code of the Redirect
+	// +kubebuilder:validation:Enum=300;301;302;303;304;305;307;308
+	// +kubebuilder:validation:Optional
+	Code int `json:"code,omitempty"`
+}
+
+// GatewayReference references a Gateway API Gateway
+type GatewayReference struct {
+	// Name is the name of the Gateway
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Gateway. Defaults to the namespace of the Redirect
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName is the name of the listener of the Gateway to attach to
+	// +kubebuilder:validation:Optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// TLSSpec holds the TLS configuration used
+type TLSSpec struct {
+	// +kubebuilder:default:=false
+	Enable      bool              `json:"enable,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Issuer requests a certificate for all source hosts from this cert-manager issuer.
+	// Requires the urlshortener to run with --enable-cert-manager. Replaces the cert-manager annotations in Annotations
+	// +kubebuilder:validation:Optional
+	Issuer *IssuerReference `json:"issuer,omitempty"`
+}
+
+// IssuerReference references a cert-manager Issuer or ClusterIssuer
+type IssuerReference struct {
+	// Name is the name of the issuer
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Kind is the kind of the issuer, Issuer or ClusterIssuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +kubebuilder:default:=ClusterIssuer
+	Kind string `json:"kind,omitempty"`
+
+	// Group is the API group of the issuer. Defaults to cert-manager.io, external issuers use their own group
+	// +kubebuilder:validation:Optional
+	Group string `json:"group,omitempty"`
+}
+
+// RedirectStatus defines the observed state of Redirect
+type RedirectStatus struct {
+	// Target is the target the source hosts are redirected to
+	// +kubebuilder:validation:Optional
+	Target string `json:"target,omitempty"`
+
+	// IngressNames are the names of the Ingresses implementing the Redirect
+	// +kubebuilder:validation:Optional
+	IngressNames []string `json:"ingressNames,omitempty"`
+
+	// Count represents how often this Redirect has been called. Only native redirects are counted
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Count int `json:"count,omitempty"`
+
+	// HTTPRouteName is the name of the HTTPRoute implementing the Redirect when using the HTTPRoute backend
+	// +kubebuilder:validation:Optional
+	HTTPRouteName string `json:"httpRouteName,omitempty"`
+
+	// CertificateName is the name of the cert-manager Certificate of the source hosts
+	// +kubebuilder:validation:Optional
+	CertificateName string `json:"certificateName,omitempty"`
+
+	// CertificateNotAfter is the time the current certificate of the source hosts expires
+	// +kubebuilder:validation:Optional
+	CertificateNotAfter *metav1.Time `json:"certificateNotAfter,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the status was computed for
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of the Redirect
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// Redirect is the Schema for the redirects API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source`
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.target`
+// +kubebuilder:printcolumn:name="Code",type=string,JSONPath=`.spec.code`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Invoked",type=string,JSONPath=`.status.count`,priority=1
+type Redirect struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RedirectSpec   `json:"spec,omitempty"`
+	Status RedirectStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RedirectList contains a list of Redirect
+type RedirectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Redirect `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Redirect{}, &RedirectList{})
+}
+
+// Hosts returns Source and all additional Sources of the Redirect, without duplicates
+func (r *Redirect) Hosts() []string {
+	hosts := []string{r.Spec.Source}
+
+	for _, source := range r.Spec.Sources {
+		if !slices.Contains(hosts, source) {
+			hosts = append(hosts, source)
+		}
+	}
+
+	return hosts
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ShortLinkTypeHTTP redirects using a HTTP 3xx status code
+	ShortLinkTypeHTTP = "HTTP"
+
+	// ShortLinkTypeHTML redirects by rendering a page which redirects via JavaScript after RedirectAfterSeconds
+	ShortLinkTypeHTML = "HTML"
+)
+
+// ShortLinkSpec defines the desired state of ShortLink
+type ShortLinkSpec struct {
+	// Owner is the GitHub user name which created the shortlink
+	// +kubebuilder:validation:Required
+	Owner string `json:"owner"`
+
+	// CoOwners are the GitHub user names which can also administrate this shortlink
+	// +kubebuilder:validation:Optional
+	CoOwners []string `json:"coOwners,omitempty"`
+
+	// Target specifies the target to which we will redirect
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Target string `json:"target"`
+
+	// Slug is the path the shortlink is served at. Slugs are case-insensitive and may contain characters
+	// which are not allowed in the name of the ShortLink, e.g. upper case, unicode or dots. Defaults to the name
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	Slug string `json:"slug,omitempty"`
+
+	// Aliases are additional slugs the shortlink is served at. Invocations of aliases are counted for the
+	// shortlink, with a breakdown per alias in the status
+	// +kubebuilder:validation:Optional
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Type selects how the client is redirected, using a HTTP status code or a HTML page
+	// +kubebuilder:validation:Enum=HTTP;HTML
+	// +kubebuilder:default:=HTTP
+	Type string `json:"type,omitempty"`
+
+	// RedirectAfterSeconds specifies after how many seconds the HTML page redirects
+	// +kubebuilder:default:=0
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=99
+	RedirectAfterSeconds int64 `json:"redirectAfterSeconds,omitempty"`
+
+	// Code is the HTTP status code used for the redirection with the HTTP type
+	// +kubebuilder:validation:Enum=300;301;302;303;304;305;307;308
+	// +kubebuilder:default:=307
+	Code int `json:"code,omitempty" enums:"300,301,302,303,304,305,307,308"`
+
+	// Description is a human readable explanation of what the shortlink is used for
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	Description string `json:"description,omitempty"`
+
+	// Tags are free-form keywords to group shortlinks, e.g. by event or team
+	// +kubebuilder:validation:Optional
+	Tags []string `json:"tags,omitempty"`
+
+	// DisableHealthCheck opts the shortlink out of the periodic health check of its target
+	// +kubebuilder:validation:Optional
+	DisableHealthCheck bool `json:"disableHealthCheck,omitempty"`
+
+	// ExpiresAt is the time after which the shortlink no longer redirects
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:date-time
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// OpenGraph is the metadata shown in the preview of the shortlink when it is shared in a chat or social network.
+	// Fields which aren't set are taken from the metadata of the target, if fetching is enabled
+	// +kubebuilder:validation:Optional
+	OpenGraph *OpenGraphSpec `json:"openGraph,omitempty"`
+
+	// QueryParameters are added to the query of the target, e.g. utm_source and utm_campaign. They take precedence
+	// over the defaults of the namespace
+	// +kubebuilder:validation:Optional
+	QueryParameters map[string]string `json:"queryParameters,omitempty"`
+
+	// QueryParameterPolicy selects whether QueryParameters and the defaults of the namespace Override parameters
+	// already in the target, or KeepExisting ones. Defaults to KeepExisting
+	// +kubebuilder:validation:Enum=Override;KeepExisting
+	// +kubebuilder:validation:Optional
+	QueryParameterPolicy string `json:"queryParameterPolicy,omitempty"`
+}
+
+// ShortLinkStatus defines the observed state of ShortLink
+type ShortLinkStatus struct {
+	// Count represents how often this ShortLink has been called, including its aliases
+	// +kubebuilder:default:=0
+	// +kubebuilder:validation:Minimum=0
+	Count int `json:"count"`
+
+	// AliasCounts represents how often this ShortLink has been called by each of its aliases
+	// +kubebuilder:validation:Optional
+	AliasCounts map[string]int `json:"aliasCounts,omitempty"`
+
+	// CrawlerCounts represents how often this ShortLink has been requested by each kind of crawler, e.g. chat apps
+	// unfurling the link or security scanners. Requests of crawlers aren't included in Count
+	// +kubebuilder:validation:Optional
+	CrawlerCounts map[string]int `json:"crawlerCounts,omitempty"`
+
+	// LastModified is the time the ShortLink was last modified
+	// +kubebuilder:validation:Optional
+	LastModified *metav1.Time `json:"lastModified,omitempty"`
+
+	// ChangedBy indicates who (GitHub User) changed the Shortlink last
+	// +kubebuilder:validation:Optional
+	ChangedBy string `json:"changedBy,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the status was computed for
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// HealthCheck is the result of the last health check of the target
+	// +kubebuilder:validation:Optional
+	HealthCheck *HealthCheckStatus `json:"healthCheck,omitempty"`
+
+	// OpenGraph is the Open Graph metadata fetched from the target
+	// +kubebuilder:validation:Optional
+	OpenGraph *OpenGraphStatus `json:"openGraph,omitempty"`
+
+	// Conditions represent the latest available observations of the ShortLink
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+const (
+	// QueryParameterPolicyOverride replaces parameters already in the target
+	QueryParameterPolicyOverride = "Override"
+
+	// QueryParameterPolicyKeepExisting keeps parameters already in the target
+	QueryParameterPolicyKeepExisting = "KeepExisting"
+)
+
+// OpenGraphSpec is the Open Graph metadata of a shortlink
+type OpenGraphSpec struct {
+	// Title is the title of the preview (og:title)
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=256
+	Title string `json:"title,omitempty"`
+
+	// Description is the description of the preview (og:description)
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	Description string `json:"description,omitempty"`
+
+	// Image is the URL of the image of the preview (og:image)
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Image string `json:"image,omitempty"`
+
+	// Fetch enables fetching the metadata which isn't set here from the target, if the urlshortener fetches metadata
+	// at all. Only publicly routable targets are fetched
+	// +kubebuilder:validation:Optional
+	Fetch bool `json:"fetch,omitempty"`
+}
+
+// OpenGraphStatus is the Open Graph metadata fetched from the target
+type OpenGraphStatus struct {
+	// Title is the og:title of the target, or the title of the page if it has none
+	// +kubebuilder:validation:Optional
+	Title string `json:"title,omitempty"`
+
+	// Description is the og:description of the target, or the description of the page if it has none
+	// +kubebuilder:validation:Optional
+	Description string `json:"description,omitempty"`
+
+	// Image is the absolute URL of the og:image of the target
+	// +kubebuilder:validation:Optional
+	Image string `json:"image,omitempty"`
+
+	// Target is the target the metadata was fetched from
+	// +kubebuilder:validation:Optional
+	Target string `json:"target,omitempty"`
+
+	// LastFetched is the time the metadata was last fetched
+	// +kubebuilder:validation:Optional
+	LastFetched metav1.Time `json:"lastFetched,omitempty"`
+
+	// Error is the reason the metadata couldn't be fetched
+	// +kubebuilder:validation:Optional
+	Error string `json:"error,omitempty"`
+}
+
+// HealthCheckStatus is the result of a health check of the target
+type HealthCheckStatus struct {
+	// LastStatusCode is the HTTP status code returned by the target. 0 if the target couldn't be reached
+	// +kubebuilder:validation:Optional
+	LastStatusCode int `json:"lastStatusCode,omitempty"`
+
+	// LatencyMilliseconds is the time it took the target to respond
+	// +kubebuilder:validation:Optional
+	LatencyMilliseconds int64 `json:"latencyMilliseconds,omitempty"`
+
+	// LastChecked is the time of the last health check
+	// +kubebuilder:validation:Optional
+	LastChecked metav1.Time `json:"lastChecked,omitempty"`
+
+	// Error is the reason the target couldn't be reached
+	// +kubebuilder:validation:Optional
+	Error string `json:"error,omitempty"`
+}
+
+// ShortLink is the Schema for the shortlinks API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.target`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Code",type=string,JSONPath=`.spec.code`
+// +kubebuilder:printcolumn:name="Invoked",type=string,JSONPath=`.status.count`
+// +kubebuilder:printcolumn:name="Reachable",type=string,JSONPath=`.status.conditions[?(@.type=="TargetReachable")].status`,priority=1
+// +kubebuilder:printcolumn:name="Description",type=string,JSONPath=`.spec.description`,priority=1
+type ShortLink struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ShortLinkSpec   `json:"spec,omitempty"`
+	Status ShortLinkStatus `json:"status,omitempty"`
+}
+
+// ShortLinkList contains a list of ShortLink
+// +kubebuilder:object:root=true
+type ShortLinkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ShortLink `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ShortLink{}, &ShortLinkList{})
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	urlshortenerv1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
+	redirectpkg "github.com/cedi/urlshortener/pkg/redirect"
+	"github.com/cedi/urlshortener/pkg/slug"
+)
+
+// newCondition returns a condition of the given type which is True with trueReason if err is nil,
+// or False with falseReason and the error as message otherwise
+func newCondition(conditionType string, generation int64, err error, trueReason string, falseReason string) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             trueReason,
+		Message:            trueReason,
+	}
+
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = falseReason
+		condition.Message = err.Error()
+	}
+
+	return condition
+}
+
+// validateTarget checks if the target of a ShortLink or Redirect can be redirected to.
+// Targets without a scheme are valid, as they are redirected to using http://
+func validateTarget(target string) error {
+	if !strings.HasPrefix(target, "http") {
+		target = "http://" + target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("target is not a valid URL: %w", err)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("target %q has no host", target)
+	}
+
+	return nil
+}
+
+// validateShortLink returns an error if a slug or the target of shortlink is invalid
+func validateShortLink(shortlink *urlshortenerv1alpha1.ShortLink) error {
+	for _, name := range shortlink.GetSlugs() {
+		if err := slug.Validate(name); err != nil {
+			return err
+		}
+	}
+
+	return validateTarget(shortlink.Spec.Target)
+}
+
+// validateRedirectSource checks if source is a valid host name for an Ingress rule
+func validateRedirectSource(source string) error {
+	if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(source, "*.")); len(errs) > 0 {
+		return fmt.Errorf("source %q is not a valid host name: %s", source, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// validateRedirect returns an error if a source host, the target or a rule of redirect is invalid.
+// Sources must not cover ownHosts, the hosts the urlshortener itself is served at. Native Redirects are invalid
+// if ownHosts are unknown, as they are routed to the urlshortener and could take over its hosts
+func validateRedirect(redirect *urlshortenerv1alpha1.Redirect, ownHosts []string) error {
+	if redirect.Spec.Native && len(ownHosts) == 0 {
+		return fmt.Errorf("native Redirects require the hosts of the urlshortener to be configured using --public-url or --shortener-hosts")
+	}
+
+	for _, host := range redirect.Hosts() {
+		if err := validateRedirectSource(host); err != nil {
+			return err
+		}
+
+		for _, own := range ownHosts {
+			if redirectpkg.CoversHost(host, own) {
+				return fmt.Errorf("source %q is a host of the urlshortener itself", host)
+			}
+		}
+	}
+
+	if err := validateTarget(redirect.Spec.Target); err != nil {
+		return err
+	}
+
+	for i, rule := range redirect.Spec.Rules {
+		if err := validateTarget(rule.Target); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+
+	_, err := redirectpkg.Rules(redirect)
+	return err
+}
+
+// earliest returns the shortest of the non-zero durations, or 0 if all are zero
+func earliest(durations ...time.Duration) time.Duration {
+	var min time.Duration
+
+	for _, d := range durations {
+		if d > 0 && (min == 0 || d < min) {
+			min = d
+		}
+	}
+
+	return min
+}
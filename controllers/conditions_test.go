@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	urlshortenerv1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
+)
+
+func TestValidateRedirect(t *testing.T) {
+	newRedirect := func(source string, native bool) *urlshortenerv1alpha1.Redirect {
+		return &urlshortenerv1alpha1.Redirect{
+			ObjectMeta: metav1.ObjectMeta{Name: "redirect", Namespace: testNamespace},
+			Spec: urlshortenerv1alpha1.RedirectSpec{
+				Source: source,
+				Target: testRedirectTarget,
+				Native: native,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		redirect *urlshortenerv1alpha1.Redirect
+		ownHosts []string
+		wantErr  bool
+	}{
+		{"ingress", newRedirect("old.example.com", false), nil, false},
+		{"native", newRedirect("old.example.com", true), []string{"go.example.com"}, false},
+		{"native without own hosts", newRedirect("old.example.com", true), nil, true},
+		{"own host", newRedirect("go.example.com", false), []string{"go.example.com"}, true},
+		{"wildcard covering own host", newRedirect("*.example.com", true), []string{"go.example.com"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := validateRedirect(test.redirect, test.ownHosts); (err != nil) != test.wantErr {
+				t.Errorf("validateRedirect() = %v, wantErr %t", err, test.wantErr)
+			}
+		})
+	}
+}
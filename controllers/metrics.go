@@ -38,8 +38,80 @@ var shortlinkInvocations = prometheus.NewGaugeVec(
 	},
 )
 
+var shortlinkAliasInvocations = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "urlshortener_shortlink_alias_invocation",
+		Help: "Counts of how often a shortlink was invoked by one of its aliases",
+	},
+	[]string{
+		"name",
+		"namespace",
+		"alias",
+	},
+)
+
+var shortlinkCrawlerInvocations = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "urlshortener_shortlink_crawler_invocation",
+		Help: "Counts of how often a shortlink was requested by crawlers, which aren't counted as invocations",
+	},
+	[]string{
+		"name",
+		"namespace",
+		"crawler",
+	},
+)
+
+var redirectInvocations = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "urlshortener_redirect_invocation",
+		Help: "Counts of how often a native redirect was invoked",
+	},
+	[]string{
+		"name",
+		"namespace",
+	},
+)
+
+var brokenShortlinks = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "urlshortener_shortlink_broken",
+		Help: "Number of shortlinks whose target failed the last health check",
+	},
+	[]string{
+		"namespace",
+	},
+)
+
+var migrationObjects = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "urlshortener_migration_objects",
+		Help: "Number of objects in the legacy urlshortener.av0.de API group by migration state",
+	},
+	[]string{
+		"kind",
+		"state",
+	},
+)
+
+var migrationFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "urlshortener_migration_failures_total",
+		Help: "Number of failed attempts to migrate an object from the legacy urlshortener.av0.de API group",
+	},
+	[]string{
+		"kind",
+	},
+)
+
 func init() {
 	metrics.Registry.MustRegister(reconcilerDuration)
 	metrics.Registry.MustRegister(active)
 	metrics.Registry.MustRegister(shortlinkInvocations)
+	metrics.Registry.MustRegister(shortlinkAliasInvocations)
+	metrics.Registry.MustRegister(shortlinkCrawlerInvocations)
+	metrics.Registry.MustRegister(redirectInvocations)
+	metrics.Registry.MustRegister(brokenShortlinks)
+	metrics.Registry.MustRegister(migrationObjects)
+	metrics.Registry.MustRegister(migrationFailures)
 }
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	urlshortenerv1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+)
+
+const (
+	// MigratedToAnnotation marks a legacy object as migrated. Its value is the namespace/name of the new object
+	MigratedToAnnotation = "urlshortener.cedi.dev/migrated-to"
+
+	// MigratedFromAnnotation is set on migrated objects to the UID of the legacy object they were copied from
+	MigratedFromAnnotation = "urlshortener.cedi.dev/migrated-from"
+)
+
+// LegacyGroupVersion is the API group the urlshortener used before moving to urlshortener.cedi.dev
+var LegacyGroupVersion = schema.GroupVersion{Group: "urlshortener.av0.de", Version: "v1alpha1"}
+
+// MigrationReconciler copies objects of one kind from the legacy API group to urlshortener.cedi.dev
+type MigrationReconciler struct {
+	client   client.Client
+	tracer   trace.Tracer
+	recorder record.EventRecorder
+
+	// kind is the kind of the migrated objects, e.g. ShortLink
+	kind string
+
+	// defaultOwner is set as the owner of migrated ShortLinks, as legacy ShortLinks have no owner
+	defaultOwner string
+}
+
+// NewMigrationReconciler returns a new MigrationReconciler migrating objects of kind.
+// Legacy ShortLinks without an owner are migrated with defaultOwner as owner
+func NewMigrationReconciler(client client.Client, tracer trace.Tracer, recorder record.EventRecorder, kind string, defaultOwner string) *MigrationReconciler {
+	return &MigrationReconciler{
+		client:       client,
+		tracer:       tracer,
+		recorder:     recorder,
+		kind:         kind,
+		defaultOwner: defaultOwner,
+	}
+}
+
+//+kubebuilder:rbac:groups=urlshortener.av0.de,resources=shortlinks;redirects,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=urlshortener.av0.de,resources=shortlinks/status;redirects/status,verbs=get
+
+// Reconcile copies a legacy object to urlshortener.cedi.dev, including its status, and marks it as migrated
+func (r *MigrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	startTime := time.Now()
+	defer func() {
+		reconcilerDuration.WithLabelValues("migration", req.Name, req.Namespace).Observe(float64(time.Since(startTime).Microseconds()))
+	}()
+
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = r.tracer.Start(ctx, "MigrationReconciler.Reconcile")
+		defer span.End()
+	}
+
+	span.SetAttributes(attribute.String("kind", r.kind), attribute.String("object", req.NamespacedName.String()))
+
+	log := otelzap.L().Sugar().With(zap.String("name", "migration"), zap.String("kind", r.kind), zap.String("object", req.NamespacedName.String()))
+
+	defer r.updateProgress(ctx)
+
+	legacy := r.newObject(LegacyGroupVersion)
+	if err := r.client.Get(ctx, req.NamespacedName, legacy); err != nil {
+		if k8serrors.IsNotFound(err) {
+			observability.RecordInfo(ctx, span, log, "Legacy object not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+
+		observability.RecordError(ctx, span, log, err, "Failed to fetch legacy object")
+		return ctrl.Result{}, err
+	}
+
+	if _, ok := legacy.GetAnnotations()[MigratedToAnnotation]; ok {
+		return ctrl.Result{}, nil
+	}
+
+	migrated, err := r.migrate(ctx, legacy)
+	if err != nil {
+		migrationFailures.WithLabelValues(r.kind).Inc()
+		r.recorder.Eventf(legacy, corev1.EventTypeWarning, "MigrationFailed", "Failed to migrate %s to %s: %v", r.kind, urlshortenerv1alpha1.GroupVersion.Group, err)
+		observability.RecordError(ctx, span, log, err, "Failed to migrate legacy object")
+		return ctrl.Result{}, err
+	}
+
+	if migrated == nil {
+		// Another object of the same name exists, which has not been migrated from this legacy object.
+		// Retrying won't resolve this until a user renames or deletes one of them
+		migrationFailures.WithLabelValues(r.kind).Inc()
+		r.recorder.Eventf(legacy, corev1.EventTypeWarning, "MigrationConflict", "A %s named %s already exists in %s, not migrating", r.kind, legacy.GetName(), urlshortenerv1alpha1.GroupVersion.Group)
+		observability.RecordInfo(ctx, span, log, "Object already exists in the new API group, not migrating")
+		return ctrl.Result{}, nil
+	}
+
+	// Mark the legacy object as migrated, so it isn't copied again after the new object was changed or deleted
+	patch := client.MergeFrom(legacy.DeepCopy())
+	annotations := legacy.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[MigratedToAnnotation] = client.ObjectKeyFromObject(migrated).String()
+	legacy.SetAnnotations(annotations)
+
+	if err := r.client.Patch(ctx, legacy, patch); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to mark legacy object as migrated")
+		return ctrl.Result{}, err
+	}
+
+	r.recorder.Eventf(legacy, corev1.EventTypeNormal, "Migrated", "Migrated %s to %s", r.kind, urlshortenerv1alpha1.GroupVersion.Group)
+	log.Infow("Migrated legacy object")
+
+	return ctrl.Result{}, nil
+}
+
+// migrate creates the copy of legacy in the new API group and copies the status of legacy to the created copy.
+// If an object of the same name exists which wasn't migrated from legacy, nil is returned
+func (r *MigrationReconciler) migrate(ctx context.Context, legacy *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	migrated := r.newObject(urlshortenerv1alpha1.GroupVersion)
+	err := r.client.Get(ctx, client.ObjectKeyFromObject(legacy), migrated)
+
+	switch {
+	case k8serrors.IsNotFound(err):
+		migrated, err = r.newMigratedObject(legacy)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := r.client.Create(ctx, migrated); err != nil {
+			return nil, errors.Wrapf(err, "Failed to create %s", r.kind)
+		}
+
+		// The status is only copied right after creating the object. Once it exists, its controller owns the
+		// status and a retry must not overwrite it with the stale status of legacy
+		if status, ok, _ := unstructured.NestedMap(legacy.Object, "status"); ok {
+			if err := unstructured.SetNestedMap(migrated.Object, status, "status"); err != nil {
+				return nil, errors.Wrapf(err, "Failed to copy status of %s", r.kind)
+			}
+
+			if err := r.client.Status().Update(ctx, migrated); err != nil {
+				return nil, errors.Wrapf(err, "Failed to update status of %s", r.kind)
+			}
+		}
+
+	case err != nil:
+		return nil, errors.Wrapf(err, "Failed to fetch %s", r.kind)
+
+	case migrated.GetAnnotations()[MigratedFromAnnotation] != string(legacy.GetUID()):
+		return nil, nil
+	}
+
+	return migrated, nil
+}
+
+// newMigratedObject returns a copy of legacy in the new API group. The labels, annotations and owner references
+// of legacy are kept. Legacy ShortLinks have no owner, so the defaultOwner is set
+func (r *MigrationReconciler) newMigratedObject(legacy *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	migrated := r.newObject(urlshortenerv1alpha1.GroupVersion)
+	migrated.SetName(legacy.GetName())
+	migrated.SetNamespace(legacy.GetNamespace())
+	migrated.SetLabels(legacy.GetLabels())
+	migrated.SetOwnerReferences(legacy.GetOwnerReferences())
+
+	annotations := make(map[string]string)
+	for key, value := range legacy.GetAnnotations() {
+		if key != corev1.LastAppliedConfigAnnotation {
+			annotations[key] = value
+		}
+	}
+	annotations[MigratedFromAnnotation] = string(legacy.GetUID())
+	migrated.SetAnnotations(annotations)
+
+	spec, ok, err := unstructured.NestedMap(legacy.Object, "spec")
+	if err != nil || !ok {
+		return nil, fmt.Errorf("%s %s has no spec", r.kind, legacy.GetName())
+	}
+
+	if r.kind == "ShortLink" {
+		if owner, _, _ := unstructured.NestedString(spec, "owner"); owner == "" {
+			if r.defaultOwner == "" {
+				return nil, fmt.Errorf("%s %s has no owner and no default owner is configured", r.kind, legacy.GetName())
+			}
+
+			spec["owner"] = r.defaultOwner
+		}
+	}
+
+	if err := unstructured.SetNestedMap(migrated.Object, spec, "spec"); err != nil {
+		return nil, errors.Wrapf(err, "Failed to copy spec of %s", r.kind)
+	}
+
+	return migrated, nil
+}
+
+// updateProgress reports how many legacy objects are migrated and how many are still pending
+func (r *MigrationReconciler) updateProgress(ctx context.Context) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(LegacyGroupVersion.WithKind(r.kind + "List"))
+
+	if err := r.client.List(ctx, list); err != nil {
+		return
+	}
+
+	migrated := 0
+	for _, item := range list.Items {
+		if _, ok := item.GetAnnotations()[MigratedToAnnotation]; ok {
+			migrated++
+		}
+	}
+
+	migrationObjects.WithLabelValues(r.kind, "migrated").Set(float64(migrated))
+	migrationObjects.WithLabelValues(r.kind, "pending").Set(float64(len(list.Items) - migrated))
+}
+
+// newObject returns an empty object of the migrated kind in groupVersion
+func (r *MigrationReconciler) newObject(groupVersion schema.GroupVersion) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(groupVersion.WithKind(r.kind))
+	return obj
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("legacy-" + strings.ToLower(r.kind)).
+		For(r.newObject(LegacyGroupVersion)).
+		Complete(r)
+}
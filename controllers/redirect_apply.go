@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	urlshortenerv1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
+	redirectpkg "github.com/cedi/urlshortener/pkg/redirect"
+)
+
+const (
+	// RedirectFieldManager is the field manager owning the fields of the objects applied for Redirects
+	RedirectFieldManager = "urlshortener"
+
+	// RedirectFinalizer makes sure the objects of a Redirect are removed before the Redirect is gone
+	RedirectFinalizer = "urlshortener.cedi.dev/redirect-cleanup"
+)
+
+// applyObject applies obj using server-side apply. Only the fields set in obj are owned by the urlshortener,
+// so labels and annotations added by others are kept. If another field manager changed a field owned by the
+// urlshortener, the drift is reported as event and the field is restored
+func (r *RedirectReconciler) applyObject(ctx context.Context, redirect *urlshortenerv1alpha1.Redirect, obj client.Object) error {
+	gvk, err := r.gvkFor(obj)
+	if err != nil {
+		return err
+	}
+
+	// Set Redirect instance as the owner and controller
+	if err := ctrl.SetControllerReference(redirect, obj, r.scheme); err != nil {
+		return errors.Wrapf(err, "Failed to set owner of %s", gvk.Kind)
+	}
+
+	// Typed objects are applied as unstructured objects, so their empty status isn't part of the applied configuration
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to convert %s", gvk.Kind)
+		}
+
+		u = &unstructured.Unstructured{Object: content}
+	}
+
+	u.SetGroupVersionKind(gvk)
+	unstructured.RemoveNestedField(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+
+	err = r.client.Patch(ctx, u, client.Apply, client.FieldOwner(RedirectFieldManager))
+	if k8serrors.IsConflict(err) {
+		r.recorder.Eventf(redirect, corev1.EventTypeWarning, "DriftDetected", "%s %s was changed by someone else, restoring it: %v", gvk.Kind, u.GetName(), err)
+
+		err = r.client.Patch(ctx, u, client.Apply, client.FieldOwner(RedirectFieldManager), client.ForceOwnership)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "Failed to apply redirect %s", gvk.Kind)
+	}
+
+	return nil
+}
+
+// cleanupStaleObjects deletes all objects controlled by redirect which aren't desired anymore,
+// e.g. after changing the backend, the ingress class or the rules of redirect
+func (r *RedirectReconciler) cleanupStaleObjects(ctx context.Context, redirect *urlshortenerv1alpha1.Redirect, desired []client.Object) error {
+	keep := make(map[string]bool)
+	for _, obj := range desired {
+		gvk, err := r.gvkFor(obj)
+		if err != nil {
+			return err
+		}
+
+		keep[objectKey(gvk, obj.GetName())] = true
+	}
+
+	for _, objType := range r.ownedTypes() {
+		gvk, err := r.gvkFor(objType)
+		if err != nil {
+			return err
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+		err = r.client.List(ctx, list,
+			client.InNamespace(redirect.Namespace),
+			client.MatchingLabels(redirectpkg.GetLabelsForRedirect(redirect.Name)),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list %s", gvk.Kind)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+
+			// Objects not controlled by redirect only share its labels by accident and are left alone
+			if keep[objectKey(gvk, obj.GetName())] || !metav1.IsControlledBy(obj, redirect) {
+				continue
+			}
+
+			if err := r.client.Delete(ctx, obj); err != nil && !k8serrors.IsNotFound(err) {
+				return errors.Wrapf(err, "Failed to delete stale %s %s", gvk.Kind, obj.GetName())
+			}
+
+			r.recorder.Eventf(redirect, corev1.EventTypeNormal, "Deleted", "Deleted stale %s %s", gvk.Kind, obj.GetName())
+		}
+	}
+
+	return nil
+}
+
+// ownedTypes returns empty objects of all types the reconciler creates for Redirects
+func (r *RedirectReconciler) ownedTypes() []client.Object {
+	types := r.options.Renderers.Types()
+
+	if r.options.GatewayAPI {
+		types = append(types, redirectpkg.NewHTTPRoute())
+	}
+
+	if r.options.CertManager {
+		types = append(types, redirectpkg.NewCertificate())
+	}
+
+	return types
+}
+
+// gvkFor returns the GroupVersionKind of obj
+func (r *RedirectReconciler) gvkFor(obj client.Object) (schema.GroupVersionKind, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.GroupVersionKind(), nil
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, r.scheme)
+	if err != nil {
+		return schema.GroupVersionKind{}, errors.Wrapf(err, "Failed to determine the kind of %T", obj)
+	}
+
+	return gvk, nil
+}
+
+// objectKey identifies an object of a namespace by its kind and name
+func objectKey(gvk schema.GroupVersionKind, name string) string {
+	return fmt.Sprintf("%s/%s", gvk.GroupKind().String(), name)
+}
+
+// finalizeRedirect deletes all objects of redirect and removes its finalizer
+func (r *RedirectReconciler) finalizeRedirect(ctx context.Context, redirect *urlshortenerv1alpha1.Redirect) error {
+	if err := r.cleanupStaleObjects(ctx, redirect, nil); err != nil {
+		return err
+	}
+
+	redirectInvocations.DeleteLabelValues(redirect.ObjectMeta.Name, redirect.ObjectMeta.Namespace)
+
+	if !controllerutil.ContainsFinalizer(redirect, RedirectFinalizer) {
+		return nil
+	}
+
+	patch := client.MergeFrom(redirect.DeepCopy())
+	controllerutil.RemoveFinalizer(redirect, RedirectFinalizer)
+
+	if err := r.client.Patch(ctx, redirect, patch); err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrap(err, "Failed to remove finalizer")
+	}
+
+	return nil
+}
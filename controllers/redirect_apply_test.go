@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	urlshortenerv1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
+	redirectclient "github.com/cedi/urlshortener/pkg/client"
+	redirectpkg "github.com/cedi/urlshortener/pkg/redirect"
+)
+
+const (
+	testNamespace         = "default"
+	redirectAnnotation    = "nginx.ingress.kubernetes.io/permanent-redirect"
+	testRedirectTarget    = "https://new.example.com"
+	testRedirectAltTarget = "https://other.example.com"
+)
+
+func newTestRedirectReconciler() (*RedirectReconciler, *record.FakeRecorder) {
+	recorder := record.NewFakeRecorder(100)
+
+	return NewRedirectReconciler(
+		k8sClient,
+		redirectclient.NewRedirectClient(k8sClient, testTracer),
+		testScheme,
+		testTracer,
+		recorder,
+		RedirectOptions{},
+	), recorder
+}
+
+func createTestRedirect(t *testing.T, name string) *urlshortenerv1alpha1.Redirect {
+	t.Helper()
+
+	redirect := &urlshortenerv1alpha1.Redirect{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec: urlshortenerv1alpha1.RedirectSpec{
+			Source:           name + ".example.com",
+			Target:           testRedirectTarget,
+			Code:             308,
+			IngressClassName: "nginx",
+		},
+	}
+
+	if err := k8sClient.Create(context.Background(), redirect); err != nil {
+		t.Fatalf("failed to create Redirect: %v", err)
+	}
+
+	return redirect
+}
+
+func reconcileRedirect(t *testing.T, r *RedirectReconciler, name string) {
+	t.Helper()
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: name}})
+	if err != nil {
+		t.Fatalf("Reconcile() failed: %v", err)
+	}
+}
+
+func updateTestRedirect(t *testing.T, name string, update func(*urlshortenerv1alpha1.Redirect)) {
+	t.Helper()
+
+	redirect := &urlshortenerv1alpha1.Redirect{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: name}, redirect); err != nil {
+		t.Fatalf("failed to get Redirect: %v", err)
+	}
+
+	update(redirect)
+
+	if err := k8sClient.Update(context.Background(), redirect); err != nil {
+		t.Fatalf("failed to update Redirect: %v", err)
+	}
+}
+
+func getIngress(t *testing.T, name string) *networkingv1.Ingress {
+	t.Helper()
+
+	ing := &networkingv1.Ingress{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: name}, ing); err != nil {
+		t.Fatalf("failed to get Ingress %s: %v", name, err)
+	}
+
+	return ing
+}
+
+func ingressExists(t *testing.T, name string) bool {
+	t.Helper()
+
+	err := k8sClient.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: name}, &networkingv1.Ingress{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		t.Fatalf("failed to get Ingress %s: %v", name, err)
+	}
+
+	return err == nil
+}
+
+// events returns the events recorded so far
+func events(recorder *record.FakeRecorder) []string {
+	recorded := []string{}
+
+	for {
+		select {
+		case event := <-recorder.Events:
+			recorded = append(recorded, event)
+		default:
+			return recorded
+		}
+	}
+}
+
+func hasEvent(recorded []string, reason string) bool {
+	for _, event := range recorded {
+		if strings.Contains(event, " "+reason+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestApplyCreatesIngress(t *testing.T) {
+	requireEnvtest(t)
+
+	r, _ := newTestRedirectReconciler()
+	redirect := createTestRedirect(t, "apply-create")
+	reconcileRedirect(t, r, redirect.Name)
+
+	ing := getIngress(t, redirect.Name)
+
+	if got := ing.Annotations[redirectAnnotation]; got != testRedirectTarget {
+		t.Errorf("annotation %s = %q, want %q", redirectAnnotation, got, testRedirectTarget)
+	}
+
+	if owner := metav1.GetControllerOf(ing); owner == nil || owner.UID != redirect.UID {
+		t.Errorf("Ingress is not controlled by the Redirect, owner is %v", owner)
+	}
+
+	applied := false
+	for _, field := range ing.ManagedFields {
+		if field.Manager == RedirectFieldManager && field.Operation == metav1.ManagedFieldsOperationApply {
+			applied = true
+		}
+	}
+
+	if !applied {
+		t.Errorf("Ingress was not applied by field manager %s: %v", RedirectFieldManager, ing.ManagedFields)
+	}
+
+	updated := &urlshortenerv1alpha1.Redirect{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(redirect), updated); err != nil {
+		t.Fatalf("failed to get Redirect: %v", err)
+	}
+
+	if len(updated.Finalizers) != 1 || updated.Finalizers[0] != RedirectFinalizer {
+		t.Errorf("finalizers = %v, want [%s]", updated.Finalizers, RedirectFinalizer)
+	}
+}
+
+func TestApplyUpdatesIngress(t *testing.T) {
+	requireEnvtest(t)
+
+	r, _ := newTestRedirectReconciler()
+	redirect := createTestRedirect(t, "apply-update")
+	reconcileRedirect(t, r, redirect.Name)
+
+	updateTestRedirect(t, redirect.Name, func(redirect *urlshortenerv1alpha1.Redirect) {
+		redirect.Spec.Target = testRedirectAltTarget
+	})
+	reconcileRedirect(t, r, redirect.Name)
+
+	if got := getIngress(t, redirect.Name).Annotations[redirectAnnotation]; got != testRedirectAltTarget {
+		t.Errorf("annotation %s = %q, want %q", redirectAnnotation, got, testRedirectAltTarget)
+	}
+}
+
+func TestApplyKeepsForeignAnnotations(t *testing.T) {
+	requireEnvtest(t)
+
+	r, _ := newTestRedirectReconciler()
+	redirect := createTestRedirect(t, "apply-foreign")
+	reconcileRedirect(t, r, redirect.Name)
+
+	// Annotations of other field managers aren't owned by the urlshortener and survive the next apply
+	ing := getIngress(t, redirect.Name)
+	patch := client.MergeFrom(ing.DeepCopy())
+	ing.Annotations["example.com/team"] = "platform"
+	if err := k8sClient.Patch(context.Background(), ing, patch, client.FieldOwner("someone-else")); err != nil {
+		t.Fatalf("failed to patch Ingress: %v", err)
+	}
+
+	updateTestRedirect(t, redirect.Name, func(redirect *urlshortenerv1alpha1.Redirect) {
+		redirect.Spec.Target = testRedirectAltTarget
+	})
+	reconcileRedirect(t, r, redirect.Name)
+
+	ing = getIngress(t, redirect.Name)
+	if got := ing.Annotations["example.com/team"]; got != "platform" {
+		t.Errorf("foreign annotation = %q, want %q", got, "platform")
+	}
+
+	if got := ing.Annotations[redirectAnnotation]; got != testRedirectAltTarget {
+		t.Errorf("annotation %s = %q, want %q", redirectAnnotation, got, testRedirectAltTarget)
+	}
+}
+
+func TestApplyCleansUpStaleObjects(t *testing.T) {
+	requireEnvtest(t)
+
+	r, recorder := newTestRedirectReconciler()
+	redirect := createTestRedirect(t, "apply-stale")
+
+	updateTestRedirect(t, redirect.Name, func(redirect *urlshortenerv1alpha1.Redirect) {
+		redirect.Spec.Rules = []urlshortenerv1alpha1.RedirectRule{{Path: "/docs/", Target: "https://docs.example.com/"}}
+	})
+	reconcileRedirect(t, r, redirect.Name)
+
+	ruleIngress := redirect.Name + "-1"
+	if !ingressExists(t, ruleIngress) {
+		t.Fatalf("Ingress %s of the rule was not created", ruleIngress)
+	}
+
+	// An object sharing the labels of the Redirect without being controlled by it must be left alone
+	foreign := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      redirect.Name + "-foreign",
+			Namespace: testNamespace,
+			Labels:    redirectpkg.GetLabelsForRedirect(redirect.Name),
+		},
+		Spec: networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{Name: "foreign", Port: networkingv1.ServiceBackendPort{Number: 80}},
+			},
+		},
+	}
+	if err := k8sClient.Create(context.Background(), foreign); err != nil {
+		t.Fatalf("failed to create foreign Ingress: %v", err)
+	}
+
+	updateTestRedirect(t, redirect.Name, func(redirect *urlshortenerv1alpha1.Redirect) {
+		redirect.Spec.Rules = nil
+	})
+	reconcileRedirect(t, r, redirect.Name)
+
+	if ingressExists(t, ruleIngress) {
+		t.Errorf("stale Ingress %s was not deleted", ruleIngress)
+	}
+
+	if !ingressExists(t, redirect.Name) {
+		t.Errorf("Ingress %s was deleted", redirect.Name)
+	}
+
+	if !ingressExists(t, foreign.Name) {
+		t.Errorf("foreign Ingress %s was deleted", foreign.Name)
+	}
+
+	if recorded := events(recorder); !hasEvent(recorded, "Deleted") {
+		t.Errorf("no Deleted event recorded: %v", recorded)
+	}
+}
+
+func TestApplyRestoresDrift(t *testing.T) {
+	requireEnvtest(t)
+
+	r, recorder := newTestRedirectReconciler()
+	redirect := createTestRedirect(t, "apply-drift")
+	reconcileRedirect(t, r, redirect.Name)
+
+	// Someone else takes over the redirect annotation, which conflicts with the next apply of the urlshortener
+	drift := &unstructured.Unstructured{}
+	drift.SetGroupVersionKind(networkingv1.SchemeGroupVersion.WithKind("Ingress"))
+	drift.SetName(redirect.Name)
+	drift.SetNamespace(testNamespace)
+	drift.SetAnnotations(map[string]string{redirectAnnotation: "https://evil.example.com"})
+
+	if err := k8sClient.Patch(context.Background(), drift, client.Apply, client.FieldOwner("someone-else"), client.ForceOwnership); err != nil {
+		t.Fatalf("failed to apply drift: %v", err)
+	}
+
+	events(recorder)
+	reconcileRedirect(t, r, redirect.Name)
+
+	if got := getIngress(t, redirect.Name).Annotations[redirectAnnotation]; got != testRedirectTarget {
+		t.Errorf("annotation %s = %q, want it restored to %q", redirectAnnotation, got, testRedirectTarget)
+	}
+
+	if recorded := events(recorder); !hasEvent(recorded, "DriftDetected") {
+		t.Errorf("no DriftDetected event recorded: %v", recorded)
+	}
+}
+
+func TestDeleteRemovesObjectsAndFinalizer(t *testing.T) {
+	requireEnvtest(t)
+
+	r, _ := newTestRedirectReconciler()
+	redirect := createTestRedirect(t, "apply-delete")
+	reconcileRedirect(t, r, redirect.Name)
+
+	if err := k8sClient.Delete(context.Background(), redirect); err != nil {
+		t.Fatalf("failed to delete Redirect: %v", err)
+	}
+
+	// The finalizer keeps the Redirect until its objects are deleted, envtest has no garbage collector
+	reconcileRedirect(t, r, redirect.Name)
+
+	if ingressExists(t, redirect.Name) {
+		t.Errorf("Ingress %s was not deleted", redirect.Name)
+	}
+
+	err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(redirect), &urlshortenerv1alpha1.Redirect{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("Redirect still exists after its finalizer ran: %v", err)
+	}
+}
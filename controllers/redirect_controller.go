@@ -18,17 +18,23 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	urlshortenerv1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
 	redirectclient "github.com/cedi/urlshortener/pkg/client"
@@ -43,17 +49,50 @@ type RedirectReconciler struct {
 	client  client.Client
 	rClient *redirectclient.RedirectClient
 
-	scheme *runtime.Scheme
-	tracer trace.Tracer
+	scheme   *runtime.Scheme
+	tracer   trace.Tracer
+	recorder record.EventRecorder
+	options  RedirectOptions
+}
+
+// RedirectOptions configures how the RedirectReconciler implements Redirects
+type RedirectOptions struct {
+	// DefaultBackend is used for Redirects which don't specify a backend. Defaults to RedirectBackendIngress
+	DefaultBackend string
+
+	// GatewayAPI enables the HTTPRoute backend. The Gateway API CRDs must be installed in the cluster
+	GatewayAPI bool
+
+	// DefaultParentRefs are the Gateways HTTPRoutes are attached to if the Redirect doesn't specify any
+	DefaultParentRefs []urlshortenerv1alpha1.GatewayReference
+
+	// Renderers select how the Ingress backend is rendered for the ingress class of a Redirect.
+	// Defaults to rendering every Redirect for ingress-nginx
+	Renderers *redirectpkg.Renderers
+
+	// NativeBackend is the Service of the urlshortener native Redirects are routed to
+	NativeBackend redirectpkg.NativeBackend
+
+	// CertManager enables requesting certificates from cert-manager. The cert-manager CRDs must be installed in the cluster
+	CertManager bool
+
+	// OwnHosts are the hosts the urlshortener itself is served at. Redirects with one of them as source are invalid
+	OwnHosts []string
 }
 
 // NewRedirectReconciler returns a new RedirectReconciler
-func NewRedirectReconciler(client client.Client, rClient *redirectclient.RedirectClient, scheme *runtime.Scheme, tracer trace.Tracer) *RedirectReconciler {
+func NewRedirectReconciler(client client.Client, rClient *redirectclient.RedirectClient, scheme *runtime.Scheme, tracer trace.Tracer, recorder record.EventRecorder, options RedirectOptions) *RedirectReconciler {
+	if options.Renderers == nil {
+		options.Renderers, _ = redirectpkg.NewRenderers(nil, nil)
+	}
+
 	return &RedirectReconciler{
-		client:  client,
-		rClient: rClient,
-		scheme:  scheme,
-		tracer:  tracer,
+		client:   client,
+		rClient:  rClient,
+		scheme:   scheme,
+		tracer:   tracer,
+		recorder: recorder,
+		options:  options,
 	}
 }
 
@@ -64,6 +103,11 @@ func NewRedirectReconciler(client client.Client, rClient *redirectclient.Redirec
 //+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses/status,verbs=get;update;patch
 
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=traefik.io,resources=middlewares;ingressroutes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=projectcontour.io,resources=httpproxies,verbs=get;list;watch;create;update;patch;delete
+
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 //
@@ -97,8 +141,7 @@ func (r *RedirectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
+			// Owned objects were deleted by the finalizer. Return and don't requeue
 			observability.RecordInfo(ctx, span, log, "Shortlink resource not found. Ignoring since object must be deleted")
 			return ctrl.Result{}, nil
 		}
@@ -108,12 +151,133 @@ func (r *RedirectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
-	// Check if the ingress already exists, if not create a new one
-	ingress, err := r.upsertRedirectIngress(ctx, redirect)
-	if err != nil {
-		observability.RecordError(ctx, span, log, err, "Failed to upsert redirect ingress")
+	if !redirect.ObjectMeta.DeletionTimestamp.IsZero() {
+		if err := r.finalizeRedirect(ctx, redirect); err != nil {
+			observability.RecordError(ctx, span, log, err, "Failed to finalize Redirect")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(redirect, RedirectFinalizer) {
+		patch := client.MergeFrom(redirect.DeepCopy())
+		controllerutil.AddFinalizer(redirect, RedirectFinalizer)
+
+		if err := r.client.Patch(ctx, redirect, patch); err != nil {
+			observability.RecordError(ctx, span, log, err, "Failed to add finalizer to Redirect")
+			return ctrl.Result{}, err
+		}
+	}
+
+	redirectInvocations.WithLabelValues(
+		redirect.ObjectMeta.Name,
+		redirect.ObjectMeta.Namespace,
+	).Set(float64(redirect.Status.Count))
+
+	original := redirect.Status.DeepCopy()
+	backend := r.backendFor(redirect)
+
+	validErr := validateRedirect(redirect, r.options.OwnHosts)
+
+	if validErr == nil && backend == urlshortenerv1alpha1.RedirectBackendHTTPRoute && !r.options.GatewayAPI {
+		validErr = fmt.Errorf("the %s backend requires the urlshortener to run with --enable-gateway-api", backend)
+	}
+
+	if validErr == nil && redirect.Spec.TLS.Enable && redirect.Spec.TLS.Issuer != nil && !r.options.CertManager {
+		validErr = fmt.Errorf("requesting certificates requires the urlshortener to run with --enable-cert-manager")
+	}
+
+	valid := newCondition(urlshortenerv1alpha1.ConditionTypeValid, redirect.Generation, validErr, "Valid", "InvalidSpec")
+	meta.SetStatusCondition(&redirect.Status.Conditions, valid)
+
+	var upsertErr error
+	var created metav1.Condition
+
+	// desired are all objects applied for redirect, all other objects controlled by it are stale
+	desired := make([]client.Object, 0)
+
+	switch backend {
+	case urlshortenerv1alpha1.RedirectBackendHTTPRoute:
+		var route *unstructured.Unstructured
+
+		// Check if the HTTPRoute already exists, if not create a new one
+		if validErr == nil {
+			route, upsertErr = r.upsertRedirectHTTPRoute(ctx, redirect)
+			if upsertErr != nil {
+				observability.RecordError(ctx, span, log, upsertErr, "Failed to upsert redirect HTTPRoute")
+			}
+		} else {
+			upsertErr = errors.Wrap(validErr, "HTTPRoute not created due to invalid spec")
+		}
+
+		if route != nil {
+			desired = append(desired, route)
+			redirect.Status.HTTPRouteName = route.GetName()
+			redirect.Status.Target = redirect.Spec.Target
+		}
+
+		created = newCondition(urlshortenerv1alpha1.ConditionTypeHTTPRouteCreated, redirect.Generation, upsertErr, "HTTPRouteCreated", "HTTPRouteUpsertFailed")
+		meta.RemoveStatusCondition(&redirect.Status.Conditions, urlshortenerv1alpha1.ConditionTypeIngressCreated)
+
+	default:
+		// Render the objects for the ingress controller of the ingress class and create or update them
+		if validErr == nil {
+			var objects []client.Object
+
+			objects, upsertErr = r.upsertRenderedObjects(ctx, redirect)
+			desired = append(desired, objects...)
+
+			if upsertErr != nil {
+				observability.RecordError(ctx, span, log, upsertErr, "Failed to upsert redirect ingress")
+			} else {
+				redirect.Status.Target = redirect.Spec.Target
+			}
+		} else {
+			upsertErr = errors.Wrap(validErr, "Ingress not created due to invalid spec")
+		}
+
+		redirect.Status.HTTPRouteName = ""
+		created = newCondition(urlshortenerv1alpha1.ConditionTypeIngressCreated, redirect.Generation, upsertErr, "IngressCreated", "IngressUpsertFailed")
+		meta.RemoveStatusCondition(&redirect.Status.Conditions, urlshortenerv1alpha1.ConditionTypeHTTPRouteCreated)
+	}
+
+	if validErr != nil {
+		created.Reason = valid.Reason
+	}
+
+	meta.SetStatusCondition(&redirect.Status.Conditions, created)
+
+	cert, certExpiresIn, certErr := r.reconcileCertificate(ctx, redirect, validErr)
+	if certErr != nil {
+		observability.RecordError(ctx, span, log, certErr, "Failed to upsert redirect Certificate")
+
+		if upsertErr == nil {
+			upsertErr = certErr
+		}
+	}
+
+	if cert != nil {
+		desired = append(desired, cert)
+	}
+
+	// Only clean up once all desired objects exist, so a failing reconcile doesn't take the redirect down
+	if validErr == nil && upsertErr == nil {
+		if err := r.cleanupStaleObjects(ctx, redirect, desired); err != nil {
+			observability.RecordError(ctx, span, log, err, "Failed to clean up stale objects")
+			upsertErr = err
+		}
+	}
+
+	ready := newCondition(urlshortenerv1alpha1.ConditionTypeReady, redirect.Generation, upsertErr, "Ready", created.Reason)
+
+	// Without a valid certificate the redirect can't be served via https
+	if cert := meta.FindStatusCondition(redirect.Status.Conditions, urlshortenerv1alpha1.ConditionTypeCertificateReady); upsertErr == nil && cert != nil && cert.Status != metav1.ConditionTrue {
+		ready = newCondition(urlshortenerv1alpha1.ConditionTypeReady, redirect.Generation, errors.New(cert.Message), "Ready", cert.Reason)
 	}
 
+	meta.SetStatusCondition(&redirect.Status.Conditions, ready)
+
 	// Update the Redirect status with the ingress name and the target
 	ingressList := &networkingv1.IngressList{}
 	listOpts := []client.ListOption{
@@ -128,43 +292,128 @@ func (r *RedirectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 
 	// Update status.Nodes if needed
 	redirect.Status.IngressName = redirectpkg.GetIngressNames(ingressList.Items)
-	redirect.Status.Target = ingress.ObjectMeta.Annotations["nginx.ingress.kubernetes.io/permanent-redirect"]
-	err = r.client.Status().Update(ctx, redirect)
-	if err != nil {
-		observability.RecordError(ctx, span, log, err, "Failed to update Redirect status")
-		return ctrl.Result{}, err
+
+	redirect.Status.ObservedGeneration = redirect.Generation
+
+	if !equality.Semantic.DeepEqual(original, &redirect.Status) {
+		if err := r.client.Status().Update(ctx, redirect); err != nil {
+			observability.RecordError(ctx, span, log, err, "Failed to update Redirect status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// An invalid spec won't get valid by retrying, we wait for the spec to change instead
+	if validErr != nil {
+		return ctrl.Result{}, nil
+	}
+
+	// Requeue when the certificate expires, in case cert-manager fails to renew it
+	return ctrl.Result{RequeueAfter: certExpiresIn}, upsertErr
+}
+
+// reconcileCertificate creates or updates the cert-manager Certificate of redirect if it requests one and
+// reflects its readiness and expiry in the status. It returns the Certificate and the time until it expires
+func (r *RedirectReconciler) reconcileCertificate(ctx context.Context, redirect *urlshortenerv1alpha1.Redirect, validErr error) (*unstructured.Unstructured, time.Duration, error) {
+	if !redirect.Spec.TLS.Enable || redirect.Spec.TLS.Issuer == nil {
+		redirect.Status.CertificateName = ""
+		redirect.Status.CertificateNotAfter = nil
+		meta.RemoveStatusCondition(&redirect.Status.Conditions, urlshortenerv1alpha1.ConditionTypeCertificateReady)
+		return nil, 0, nil
+	}
+
+	// The Valid condition already reports why the certificate can't be requested
+	if validErr != nil {
+		return nil, 0, nil
+	}
+
+	cert := redirectpkg.NewRedirectCertificate(redirect)
+	if err := r.applyObject(ctx, redirect, cert); err != nil {
+		meta.SetStatusCondition(&redirect.Status.Conditions, newCondition(urlshortenerv1alpha1.ConditionTypeCertificateReady, redirect.Generation, err, "CertificateReady", "CertificateUpsertFailed"))
+		return nil, 0, err
+	}
+
+	redirect.Status.CertificateName = cert.GetName()
+
+	notAfter, certErr := redirectpkg.CertificateStatus(cert)
+	condition := newCondition(urlshortenerv1alpha1.ConditionTypeCertificateReady, redirect.Generation, certErr, "CertificateReady", "CertificateNotReady")
+
+	var expiresIn time.Duration
+	redirect.Status.CertificateNotAfter = nil
+
+	if notAfter != nil {
+		redirect.Status.CertificateNotAfter = &metav1.Time{Time: *notAfter}
+
+		if certErr == nil {
+			condition.Message = fmt.Sprintf("Certificate is valid until %s", notAfter.Format(time.RFC3339))
+			expiresIn = time.Until(*notAfter)
+		}
 	}
 
-	return ctrl.Result{}, nil
+	meta.SetStatusCondition(&redirect.Status.Conditions, condition)
+
+	return cert, expiresIn, nil
 }
 
-func (r *RedirectReconciler) upsertRedirectIngress(ctx context.Context, redirect *urlshortenerv1alpha1.Redirect) (*networkingv1.Ingress, error) {
-	ingress := &networkingv1.Ingress{}
-	err := r.client.Get(ctx, types.NamespacedName{Name: redirect.Name, Namespace: redirect.Namespace}, ingress)
-	ingress = redirectpkg.NewRedirectIngress(ingress, redirect)
+// upsertRenderedObjects renders redirect using the renderer of its ingress class and applies the rendered
+// objects. Native redirects are routed to the urlshortener regardless of the ingress class
+func (r *RedirectReconciler) upsertRenderedObjects(ctx context.Context, redirect *urlshortenerv1alpha1.Redirect) ([]client.Object, error) {
+	var objects []client.Object
+
+	if redirect.Spec.Native {
+		objects = []client.Object{redirectpkg.NewNativeIngress(redirect, r.options.NativeBackend)}
+	} else {
+		var err error
 
-	// Set Redirect instance as the owner and controller
-	ctrl.SetControllerReference(redirect, ingress, r.scheme)
+		objects, err = r.options.Renderers.For(redirect.Spec.IngressClassName).Render(redirect)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to render redirect")
+		}
+	}
 
-	if err != nil && k8serrors.IsNotFound(err) {
-		if err := r.client.Create(ctx, ingress); err != nil {
-			return nil, errors.Wrap(err, "Failed to create new Ingress")
+	for _, obj := range objects {
+		if err := r.applyObject(ctx, redirect, obj); err != nil {
+			return nil, err
 		}
-	} else if err != nil {
-		return nil, errors.Wrap(err, "Failed to get redirect Ingress")
 	}
 
-	if err := r.client.Update(ctx, ingress); err != nil {
-		return nil, errors.Wrap(err, "Failed to update redirect Ingress")
+	return objects, nil
+}
+
+func (r *RedirectReconciler) upsertRedirectHTTPRoute(ctx context.Context, redirect *urlshortenerv1alpha1.Redirect) (*unstructured.Unstructured, error) {
+	route, err := redirectpkg.NewRedirectHTTPRoute(nil, redirect, r.options.DefaultParentRefs, r.options.NativeBackend)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.applyObject(ctx, redirect, route); err != nil {
+		return nil, err
+	}
+
+	return route, nil
+}
+
+// backendFor returns the backend implementing redirect
+func (r *RedirectReconciler) backendFor(redirect *urlshortenerv1alpha1.Redirect) string {
+	if redirect.Spec.Backend != "" {
+		return redirect.Spec.Backend
+	}
+
+	if r.options.DefaultBackend != "" {
+		return r.options.DefaultBackend
 	}
 
-	return ingress, nil
+	return urlshortenerv1alpha1.RedirectBackendIngress
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *RedirectReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&urlshortenerv1alpha1.Redirect{}).
-		Owns(&networkingv1.Ingress{}).
-		Complete(r)
+	builder := ctrl.NewControllerManagedBy(mgr).
+		For(&urlshortenerv1alpha1.Redirect{})
+
+	// Only the types of enabled renderers and integrations are watched, as the watch fails if their CRDs are not installed
+	for _, obj := range r.ownedTypes() {
+		builder = builder.Owns(obj)
+	}
+
+	return builder.Complete(r)
 }
@@ -18,41 +18,101 @@ package controllers
 
 import (
 	"context"
+	goerrors "errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	v1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
 	shortlinkclient "github.com/cedi/urlshortener/pkg/client"
+	"github.com/cedi/urlshortener/pkg/healthcheck"
 	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/cedi/urlshortener/pkg/opengraph"
+	"github.com/cedi/urlshortener/pkg/workpool"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 )
 
+const (
+	// workQueueSize is the number of health checks and Open Graph fetches each waiting for a worker.
+	// Further targets are retried later on
+	workQueueSize = 1024
+
+	// workRetryInterval is how long a ShortLink waits before its target is submitted to a worker again if the queue
+	// was full, or before the result is looked for if it wasn't reported
+	workRetryInterval = time.Minute
+)
+
+// duplicateSlugRecheckInterval is how often a ShortLink using a slug claimed by another ShortLink checks if the
+// slug was released
+const duplicateSlugRecheckInterval = time.Minute
+
 // ShortLinkReconciler reconciles a ShortLink object
 type ShortLinkReconciler struct {
 	client *shortlinkclient.ShortlinkClient
 	scheme *runtime.Scheme
 	tracer trace.Tracer
+
+	// healthChecks probes the targets of the ShortLinks every healthCheckInterval. A zero interval disables health
+	// checks
+	healthChecks        *workpool.Pool[*healthcheck.Result]
+	healthCheckInterval time.Duration
+
+	// openGraphFetches fetches the Open Graph metadata of the targets every openGraphInterval. A zero interval
+	// disables fetching
+	openGraphFetches  *workpool.Pool[*opengraph.Metadata]
+	openGraphInterval time.Duration
+
+	// worked reconciles a ShortLink again once a health check or Open Graph fetch of its target finished,
+	// to record the result
+	worked chan event.GenericEvent
 }
 
 // NewShortLinkReconciler returns a new ShortLinkReconciler
-func NewShortLinkReconciler(client *shortlinkclient.ShortlinkClient, scheme *runtime.Scheme, tracer trace.Tracer) *ShortLinkReconciler {
-	return &ShortLinkReconciler{
-		client: client,
-		scheme: scheme,
-		tracer: tracer,
+// The health checks of checker run on healthCheckWorkers workers, the fetches of fetcher on openGraphWorkers workers
+func NewShortLinkReconciler(client *shortlinkclient.ShortlinkClient, scheme *runtime.Scheme, tracer trace.Tracer, checker *healthcheck.Checker, healthCheckInterval time.Duration, healthCheckWorkers int, fetcher *opengraph.Fetcher, openGraphInterval time.Duration, openGraphWorkers int) *ShortLinkReconciler {
+	r := &ShortLinkReconciler{
+		client:              client,
+		scheme:              scheme,
+		tracer:              tracer,
+		healthCheckInterval: healthCheckInterval,
+		openGraphInterval:   openGraphInterval,
+		worked:              make(chan event.GenericEvent, 2*workQueueSize),
+	}
+
+	r.healthChecks = workpool.New(checker.Check, healthCheckWorkers, workQueueSize, r.reconcileAgain)
+	r.openGraphFetches = workpool.New(fetcher.Fetch, openGraphWorkers, workQueueSize, r.reconcileAgain)
+
+	return r
+}
+
+// reconcileAgain enqueues the ShortLink named key, as namespace/name
+func (r *ShortLinkReconciler) reconcileAgain(key string) {
+	namespace, name, _ := strings.Cut(key, "/")
+	r.worked <- event.GenericEvent{
+		Object: &v1alpha1.ShortLink{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}},
 	}
 }
 
 //+kubebuilder:rbac:groups=urlshortener.cedi.dev,resources=shortlinks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=urlshortener.cedi.dev,resources=shortlinks/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=urlshortener.cedi.dev,resources=shortlinks/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -87,23 +147,280 @@ func (r *ShortLinkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
-	if shortlinkList, err := r.client.ListNamespaced(ctx, req.Namespace); shortlinkList != nil && err == nil {
+	shortlinkList, err := r.client.ListNamespaced(ctx, req.Namespace)
+	if shortlinkList != nil && err == nil {
 		active.WithLabelValues("shortlink").Set(float64(len(shortlinkList.Items)))
 
+		broken := 0
 		for _, shortlink := range shortlinkList.Items {
 			shortlinkInvocations.WithLabelValues(
 				shortlink.ObjectMeta.Name,
 				shortlink.ObjectMeta.Namespace,
 			).Set(float64(shortlink.Status.Count))
+
+			for alias, count := range shortlink.Status.AliasCounts {
+				shortlinkAliasInvocations.WithLabelValues(
+					shortlink.ObjectMeta.Name,
+					shortlink.ObjectMeta.Namespace,
+					alias,
+				).Set(float64(count))
+			}
+
+			for crawler, count := range shortlink.Status.CrawlerCounts {
+				shortlinkCrawlerInvocations.WithLabelValues(
+					shortlink.ObjectMeta.Name,
+					shortlink.ObjectMeta.Namespace,
+					crawler,
+				).Set(float64(count))
+			}
+
+			if meta.IsStatusConditionFalse(shortlink.Status.Conditions, v1alpha1.ConditionTypeTargetReachable) {
+				broken++
+			}
 		}
+
+		brokenShortlinks.WithLabelValues(req.Namespace).Set(float64(broken))
+	}
+
+	if shortlink == nil {
+		return ctrl.Result{}, nil
+	}
+
+	original := shortlink.Status.DeepCopy()
+
+	conditionsIn, err := r.reconcileConditions(ctx, shortlink)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to claim slugs")
+		return ctrl.Result{}, err
+	}
+
+	healthCheckIn, err := r.reconcileHealthCheck(ctx, shortlink)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to check target")
+		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	openGraphIn := r.reconcileOpenGraph(ctx, shortlink)
+
+	shortlink.Status.ObservedGeneration = shortlink.Generation
+
+	if !equality.Semantic.DeepEqual(original, &shortlink.Status) {
+		if err := r.client.UpdateStatus(ctx, shortlink); err != nil {
+			observability.RecordError(ctx, span, log, err, "Failed to update ShortLink status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: earliest(conditionsIn, earliest(healthCheckIn, openGraphIn))}, nil
+}
+
+// reconcileConditions sets the Valid, Expired and Ready conditions of shortlink. The slugs of a valid shortlink are
+// claimed, so that ShortLinks applied with kubectl can't use the slugs of others either. A slug claimed by
+// another ShortLink is reported as DuplicateSlug. The time until the claim is checked again or shortlink expires,
+// whichever is earlier, is returned. The slugs are only claimed once per generation, as every request of the shortlink updates its status and
+// triggers a reconcile, while claiming reads the claims from the API server
+func (r *ShortLinkReconciler) reconcileConditions(ctx context.Context, shortlink *v1alpha1.ShortLink) (time.Duration, error) {
+	var duplicateIn, expiresIn time.Duration
+
+	valid := newCondition(v1alpha1.ConditionTypeValid, shortlink.Generation, validateShortLink(shortlink), "Valid", "Invalid")
+
+	if valid.Status == metav1.ConditionTrue && !slugsClaimed(shortlink) {
+		if err := r.client.ClaimSlugs(ctx, shortlink); err != nil {
+			var conflict *shortlinkclient.SlugConflictError
+			if !goerrors.As(err, &conflict) {
+				return 0, err
+			}
+
+			valid = newCondition(v1alpha1.ConditionTypeValid, shortlink.Generation, err, "Valid", "DuplicateSlug")
+			duplicateIn = duplicateSlugRecheckInterval
+		}
+	}
+
+	meta.SetStatusCondition(&shortlink.Status.Conditions, valid)
+
+	expired := metav1.Condition{
+		Type:               v1alpha1.ConditionTypeExpired,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: shortlink.Generation,
+		Reason:             "NotExpired",
+		Message:            "ShortLink does not expire",
+	}
+
+	if shortlink.Spec.ExpiresAt != nil {
+		expired.Message = fmt.Sprintf("ShortLink expires at %s", shortlink.Spec.ExpiresAt.UTC().Format(time.RFC3339))
+		expiresIn = time.Until(shortlink.Spec.ExpiresAt.Time)
+
+		if shortlink.IsExpired() {
+			expired.Status = metav1.ConditionTrue
+			expired.Reason = "Expired"
+			expired.Message = fmt.Sprintf("ShortLink expired at %s", shortlink.Spec.ExpiresAt.UTC().Format(time.RFC3339))
+			expiresIn = 0
+		}
+	}
+
+	meta.SetStatusCondition(&shortlink.Status.Conditions, expired)
+
+	ready := metav1.Condition{
+		Type:               v1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: shortlink.Generation,
+		Reason:             "Ready",
+		Message:            "ShortLink is served",
+	}
+
+	switch {
+	case valid.Status != metav1.ConditionTrue:
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = valid.Reason
+		ready.Message = valid.Message
+	case expired.Status == metav1.ConditionTrue:
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = expired.Reason
+		ready.Message = expired.Message
+	}
+
+	meta.SetStatusCondition(&shortlink.Status.Conditions, ready)
+
+	return earliest(duplicateIn, expiresIn), nil
+}
+
+// slugsClaimed reports whether the slugs of the current generation of shortlink were claimed already
+func slugsClaimed(shortlink *v1alpha1.ShortLink) bool {
+	valid := meta.FindStatusCondition(shortlink.Status.Conditions, v1alpha1.ConditionTypeValid)
+
+	return valid != nil && valid.Status == metav1.ConditionTrue && valid.ObservedGeneration == shortlink.Generation
+}
+
+// reconcileHealthCheck probes the target of shortlink if the last check is older than the health check interval
+// and records the result in the status of shortlink. The time until the next check is due is returned
+func (r *ShortLinkReconciler) reconcileHealthCheck(ctx context.Context, shortlink *v1alpha1.ShortLink) (time.Duration, error) {
+	if r.healthCheckInterval == 0 || shortlink.Spec.DisableHealthCheck {
+		shortlink.Status.HealthCheck = nil
+		meta.RemoveStatusCondition(&shortlink.Status.Conditions, v1alpha1.ConditionTypeTargetReachable)
+		return 0, nil
+	}
+
+	log := otelzap.L().Sugar().With(zap.String("name", "reconciler"), zap.String("shortlink", shortlink.Name), zap.String("target", shortlink.Spec.Target))
+
+	// Only probe the target again once the interval passed, unless the spec (and therefore possibly the target) changed
+	condition := meta.FindStatusCondition(shortlink.Status.Conditions, v1alpha1.ConditionTypeTargetReachable)
+	if shortlink.Status.HealthCheck != nil && condition != nil && condition.ObservedGeneration == shortlink.Generation {
+		if next := time.Until(shortlink.Status.HealthCheck.LastChecked.Add(r.healthCheckInterval)); next > 0 {
+			return next, nil
+		}
+	}
+
+	// The check runs on the workers of the pool, which reconcile the ShortLink again once the result is available
+	key := client.ObjectKeyFromObject(shortlink).String()
+	result, err, ok := r.healthChecks.Result(key, shortlink.Spec.Target)
+	if !ok {
+		r.healthChecks.Submit(key, shortlink.Spec.Target)
+		return workRetryInterval, nil
+	}
+
+	if err != nil {
+		var rateLimited *healthcheck.RateLimitedError
+		if goerrors.As(err, &rateLimited) {
+			return rateLimited.RetryAfter, nil
+		}
+
+		return 0, err
+	}
+
+	shortlink.Status.HealthCheck = &v1alpha1.HealthCheckStatus{
+		LastStatusCode:      result.StatusCode,
+		LatencyMilliseconds: result.Latency.Milliseconds(),
+		LastChecked:         metav1.Now(),
+	}
+
+	reachable := metav1.Condition{
+		Type:               v1alpha1.ConditionTypeTargetReachable,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: shortlink.Generation,
+		Reason:             "TargetReachable",
+		Message:            fmt.Sprintf("Target responded with %d", result.StatusCode),
+	}
+
+	if !result.Reachable() {
+		reachable.Status = metav1.ConditionFalse
+		reachable.Reason = "TargetUnreachable"
+
+		if result.Err != nil {
+			shortlink.Status.HealthCheck.Error = result.Err.Error()
+			reachable.Message = result.Err.Error()
+		}
+
+		log.Infow("Target is unreachable", zap.Int("status_code", result.StatusCode), zap.String("error", shortlink.Status.HealthCheck.Error))
+	}
+
+	meta.SetStatusCondition(&shortlink.Status.Conditions, reachable)
+
+	return r.healthCheckInterval, nil
+}
+
+// reconcileOpenGraph fetches the Open Graph metadata of the target of shortlink if it changed or the last fetch is older
+// than the Open Graph interval, and caches it in the status of shortlink. The time until the next fetch is due is returned
+func (r *ShortLinkReconciler) reconcileOpenGraph(ctx context.Context, shortlink *v1alpha1.ShortLink) time.Duration {
+	// Only fetch if the owner opted in, and nothing needs to be fetched if the spec sets all metadata
+	if openGraph := shortlink.Spec.OpenGraph; r.openGraphInterval == 0 || openGraph == nil || !openGraph.Fetch || openGraph.Title != "" && openGraph.Description != "" && openGraph.Image != "" {
+		shortlink.Status.OpenGraph = nil
+		return 0
+	}
+
+	if fetched := shortlink.Status.OpenGraph; fetched != nil && fetched.Target == shortlink.Spec.Target {
+		if next := time.Until(fetched.LastFetched.Add(r.openGraphInterval)); next > 0 {
+			return next
+		}
+	}
+
+	log := otelzap.L().Sugar().With(zap.String("name", "reconciler"), zap.String("shortlink", shortlink.Name), zap.String("target", shortlink.Spec.Target))
+
+	// The metadata is fetched by the workers of the pool, which reconcile the ShortLink again once it is available
+	key := client.ObjectKeyFromObject(shortlink).String()
+	metadata, err, ok := r.openGraphFetches.Result(key, shortlink.Spec.Target)
+	if !ok {
+		r.openGraphFetches.Submit(key, shortlink.Spec.Target)
+		return workRetryInterval
+	}
+
+	status := &v1alpha1.OpenGraphStatus{
+		Target:      shortlink.Spec.Target,
+		LastFetched: metav1.Now(),
+	}
+
+	if err != nil {
+		// The target may be down for a moment, keep the metadata fetched before
+		if previous := shortlink.Status.OpenGraph; previous != nil && previous.Target == shortlink.Spec.Target {
+			status.Title = previous.Title
+			status.Description = previous.Description
+			status.Image = previous.Image
+		}
+
+		status.Error = err.Error()
+		log.Infow("Failed to fetch Open Graph metadata of target", zap.Error(err))
+	} else {
+		status.Title = metadata.Title
+		status.Description = metadata.Description
+		status.Image = metadata.Image
+	}
+
+	shortlink.Status.OpenGraph = status
+
+	return r.openGraphInterval
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ShortLinkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.Add(r.healthChecks); err != nil {
+		return err
+	}
+
+	if err := mgr.Add(r.openGraphFetches); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.ShortLink{}).
+		Watches(&source.Channel{Source: r.worked}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
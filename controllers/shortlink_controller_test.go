@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	urlshortenerv1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
+	shortlinkclient "github.com/cedi/urlshortener/pkg/client"
+)
+
+func TestReconcileConditionsClaimsSlugsOncePerGeneration(t *testing.T) {
+	shortlink := &urlshortenerv1alpha1.ShortLink{
+		ObjectMeta: metav1.ObjectMeta{Name: "shortlink", Namespace: testNamespace, UID: "uid-shortlink", Generation: 1},
+		Spec:       urlshortenerv1alpha1.ShortLinkSpec{Owner: "octocat", Target: "https://example.com"},
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(shortlink).Build()
+	r := &ShortLinkReconciler{client: shortlinkclient.NewShortlinkClient(fakeClient, fakeClient, testTracer)}
+
+	claims := func() int {
+		list := &corev1.ConfigMapList{}
+		if err := fakeClient.List(context.Background(), list, client.InNamespace(testNamespace)); err != nil {
+			t.Fatalf("failed to list claims: %v", err)
+		}
+
+		return len(list.Items)
+	}
+
+	if _, err := r.reconcileConditions(context.Background(), shortlink); err != nil {
+		t.Fatalf("reconcileConditions() failed: %v", err)
+	}
+
+	if !meta.IsStatusConditionTrue(shortlink.Status.Conditions, urlshortenerv1alpha1.ConditionTypeValid) || claims() != 1 {
+		t.Fatalf("reconcileConditions() didn't claim the slug: %v", shortlink.Status.Conditions)
+	}
+
+	// Status updates, e.g. of the request count, don't claim the slugs again
+	if err := fakeClient.DeleteAllOf(context.Background(), &corev1.ConfigMap{}, client.InNamespace(testNamespace)); err != nil {
+		t.Fatalf("failed to delete claims: %v", err)
+	}
+
+	if _, err := r.reconcileConditions(context.Background(), shortlink); err != nil {
+		t.Fatalf("reconcileConditions() failed: %v", err)
+	}
+
+	if claims() != 0 {
+		t.Errorf("reconcileConditions() claimed the slugs of an unchanged spec again")
+	}
+
+	shortlink.Generation++
+	if _, err := r.reconcileConditions(context.Background(), shortlink); err != nil {
+		t.Fatalf("reconcileConditions() failed: %v", err)
+	}
+
+	if claims() != 1 {
+		t.Errorf("reconcileConditions() didn't claim the slugs of a changed spec")
+	}
+}
+
+func TestReconcileConditionsExpired(t *testing.T) {
+	expiresAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	shortlink := &urlshortenerv1alpha1.ShortLink{
+		ObjectMeta: metav1.ObjectMeta{Name: "shortlink", Namespace: testNamespace, UID: "uid-shortlink", Generation: 1},
+		Spec:       urlshortenerv1alpha1.ShortLinkSpec{Owner: "octocat", Target: "https://example.com", ExpiresAt: &expiresAt},
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(shortlink).Build()
+	r := &ShortLinkReconciler{client: shortlinkclient.NewShortlinkClient(fakeClient, fakeClient, testTracer)}
+
+	if _, err := r.reconcileConditions(context.Background(), shortlink); err != nil {
+		t.Fatalf("reconcileConditions() failed: %v", err)
+	}
+
+	if !meta.IsStatusConditionTrue(shortlink.Status.Conditions, urlshortenerv1alpha1.ConditionTypeExpired) {
+		t.Errorf("Expired condition isn't true: %v", shortlink.Status.Conditions)
+	}
+
+	if ready := meta.FindStatusCondition(shortlink.Status.Conditions, urlshortenerv1alpha1.ConditionTypeReady); ready == nil || ready.Reason != "Expired" {
+		t.Errorf("Ready condition = %v, want reason Expired", ready)
+	}
+
+	// A ShortLink expiring in the future is reconciled again once it expired
+	expiresAt = metav1.NewTime(time.Now().Add(time.Hour))
+	requeueIn, err := r.reconcileConditions(context.Background(), shortlink)
+	if err != nil {
+		t.Fatalf("reconcileConditions() failed: %v", err)
+	}
+
+	if requeueIn <= 0 || requeueIn > time.Hour {
+		t.Errorf("reconcileConditions() = %v, want the time until the ShortLink expires", requeueIn)
+	}
+
+	if !meta.IsStatusConditionFalse(shortlink.Status.Conditions, urlshortenerv1alpha1.ConditionTypeExpired) {
+		t.Errorf("Expired condition isn't false: %v", shortlink.Status.Conditions)
+	}
+}
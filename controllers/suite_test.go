@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	urlshortenerv1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
+	urlshortenerv1beta1 "github.com/cedi/urlshortener/api/v1beta1"
+)
+
+var (
+	testScheme = runtime.NewScheme()
+	testTracer = trace.NewNoopTracerProvider().Tracer("test")
+
+	// k8sClient talks to the API server started by envtest. nil if the tests run without envtest
+	k8sClient client.Client
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+	utilruntime.Must(urlshortenerv1alpha1.AddToScheme(testScheme))
+	utilruntime.Must(urlshortenerv1beta1.AddToScheme(testScheme))
+}
+
+// TestMain starts an API server using envtest if KUBEBUILDER_ASSETS is set, as done by make test
+func TestMain(m *testing.M) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		os.Exit(m.Run())
+	}
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start envtest: %v\n", err)
+		os.Exit(1)
+	}
+
+	k8sClient, err = client.New(cfg, client.Options{Scheme: testScheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create client: %v\n", err)
+		_ = testEnv.Stop()
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	if err := testEnv.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to stop envtest: %v\n", err)
+	}
+
+	os.Exit(code)
+}
+
+// requireEnvtest skips tests which need an API server if envtest isn't available
+func requireEnvtest(t *testing.T) {
+	t.Helper()
+
+	if k8sClient == nil {
+		t.Skip("KUBEBUILDER_ASSETS is not set, run the tests using make test")
+	}
+}
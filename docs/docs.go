@@ -40,6 +40,84 @@ const docTemplate = `{
                     "api/v1/"
                 ],
                 "summary": "list shortlinks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "maximum number of shortlinks per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "continue token returned in the X-Continue header of the previous page",
+                        "name": "continue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only list shortlinks owned by this user",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only list shortlinks whose target is on this domain",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Kubernetes label selector, e.g. team=platform",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only list shortlinks tagged with all of the given tags",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only list shortlinks created after this RFC 3339 date-time",
+                        "name": "createdAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only list shortlinks created before this RFC 3339 date-time",
+                        "name": "createdBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only list shortlinks modified after this RFC 3339 date-time",
+                        "name": "modifiedAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only list shortlinks modified before this RFC 3339 date-time",
+                        "name": "modifiedBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort by name, count or lastModified. Prefix with - to sort descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "full-text search over name, target and description",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Success",
@@ -48,24 +126,36 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/controller.ShortLink"
                             }
+                        },
+                        "headers": {
+                            "X-Continue": {
+                                "type": "string",
+                                "description": "continue token to fetch the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "404": {
-                        "description": "NotFound",
+                    "429": {
+                        "description": "TooManyRequests",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
                         "description": "InternalServerError",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
@@ -106,19 +196,31 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "404": {
                         "description": "NotFound",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "TooManyRequests",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
                         "description": "InternalServerError",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
@@ -129,7 +231,7 @@ const docTemplate = `{
                         "bearerAuth": []
                     }
                 ],
-                "description": "update a new shortlink",
+                "description": "replace the spec of a shortlink. The owner, co-owners, slug and code are kept if they are omitted, all other omitted fields are reset",
                 "consumes": [
                     "application/json"
                 ],
@@ -151,38 +253,74 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "shortlink spec",
+                        "description": "shortlink spec and labels",
                         "name": "spec",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1alpha1.ShortLinkSpec"
+                            "$ref": "#/definitions/controller.ShortLinkRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "only update if the ETag of the shortlink matches",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.ShortLink"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "404": {
                         "description": "NotFound",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "PreconditionFailed",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "TooManyRequests",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
                         "description": "InternalServerError",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
@@ -209,17 +347,17 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "example": "home",
-                        "description": "the shortlink URL part (shortlink id)",
+                        "description": "the shortlink URL part (shortlink id). Case-insensitive, must not be reserved",
                         "name": "shortlink",
                         "in": "path"
                     },
                     {
-                        "description": "shortlink spec",
+                        "description": "shortlink spec and labels",
                         "name": "spec",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1alpha1.ShortLinkSpec"
+                            "$ref": "#/definitions/controller.ShortLinkRequest"
                         }
                     }
                 ],
@@ -227,7 +365,7 @@ const docTemplate = `{
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.ShortLink"
                         }
                     },
                     "301": {
@@ -254,22 +392,40 @@ const docTemplate = `{
                             "type": "integer"
                         }
                     },
+                    "400": {
+                        "description": "BadRequest",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "404": {
-                        "description": "NotFound",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "TooManyRequests",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
                         "description": "InternalServerError",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
@@ -309,150 +465,581 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "404": {
                         "description": "NotFound",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "TooManyRequests",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
                         "description": "InternalServerError",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/{shortlink}": {
-            "get": {
-                "description": "redirect to target as per configuration of the shortlink",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "partially update a shortlink using a JSON Merge Patch (RFC 7386) or a JSON Patch (RFC 6902) of the ShortLinkRequest document (spec and labels)",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
                 "produces": [
-                    "text/html"
+                    "text/plain",
+                    "application/json"
                 ],
                 "tags": [
-                    "default"
+                    "api/v1/"
                 ],
-                "summary": "redirect to target",
+                "summary": "patch existing shortlink",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "shortlink id",
+                        "example": "home",
+                        "description": "the shortlink URL part (shortlink id)",
                         "name": "shortlink",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "patch of the shortlink spec",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "only update if the ETag of the shortlink matches",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.ShortLink"
                         }
                     },
-                    "300": {
-                        "description": "MultipleChoices",
+                    "400": {
+                        "description": "BadRequest",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "301": {
-                        "description": "MovedPermanently",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "302": {
-                        "description": "Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "303": {
-                        "description": "SeeOther",
+                    "404": {
+                        "description": "NotFound",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "304": {
-                        "description": "NotModified",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "305": {
-                        "description": "UseProxy",
+                    "412": {
+                        "description": "PreconditionFailed",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "307": {
-                        "description": "TemporaryRedirect",
+                    "415": {
+                        "description": "UnsupportedMediaType",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "308": {
-                        "description": "PermanentRedirect",
+                    "429": {
+                        "description": "TooManyRequests",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "InternalServerError",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/shortlink/{shortlink}/history": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "get all recorded create and update operations of a shortlink, oldest first",
+                "produces": [
+                    "text/plain",
+                    "application/json"
+                ],
+                "tags": [
+                    "api/v1/"
+                ],
+                "summary": "get the history of a shortlink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "home",
+                        "description": "the shortlink URL part (shortlink id)",
+                        "name": "shortlink",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AuditEntry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "404": {
                         "description": "NotFound",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "TooManyRequests",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
                         "description": "InternalServerError",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controller.ShortLink": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                },
-                "spec": {
-                    "$ref": "#/definitions/v1alpha1.ShortLinkSpec"
-                },
-                "status": {
-                    "$ref": "#/definitions/v1alpha1.ShortLinkStatus"
-                }
-            }
         },
-        "v1alpha1.ShortLinkSpec": {
-            "type": "object",
-            "properties": {
-                "after": {
-                    "description": "RedirectAfter specifies after how many seconds to redirect (Default=3)\n+kubebuilder:default:=0\n+kubebuilder:validation:Minimum=0\n+kubebuilder:validation:Maximum=99",
-                    "type": "integer"
-                },
-                "code": {
-                    "description": "Code is the URL Code used for the redirection.\nleave on default (307) when using the HTML behavior. However, if you whish to use a HTTP 3xx redirect, set to the appropriate 3xx status code\n+kubebuilder:validation:Enum=200;300;301;302;303;304;305;307;308\n+kubebuilder:default:=307",
-                    "type": "integer",
-                    "enum": [
-                        200,
-                        300,
-                        301,
-                        302,
-                        303,
-                        304,
-                        305,
-                        307,
-                        308
-                    ]
+        "/api/v1/shortlink/{shortlink}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "list the recorded spec revisions of a shortlink, oldest first. The latest revision is the current spec",
+                "produces": [
+                    "text/plain",
+                    "application/json"
+                ],
+                "tags": [
+                    "api/v1/"
+                ],
+                "summary": "list the revisions of a shortlink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "home",
+                        "description": "the shortlink URL part (shortlink id)",
+                        "name": "shortlink",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Revision"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "NotFound",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "TooManyRequests",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "InternalServerError",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/shortlink/{shortlink}/rollback": {
+            "post": {
+                "security": [
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "restore the spec of a previous revision of a shortlink. The owners of the shortlink are kept",
+                "produces": [
+                    "text/plain",
+                    "application/json"
+                ],
+                "tags": [
+                    "api/v1/"
+                ],
+                "summary": "rollback shortlink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "home",
+                        "description": "the shortlink URL part (shortlink id)",
+                        "name": "shortlink",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "the revision to restore",
+                        "name": "revision",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only rollback if the ETag of the shortlink matches",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/controller.ShortLink"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "NotFound",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "PreconditionFailed",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "TooManyRequests",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "InternalServerError",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/{shortlink}": {
+            "get": {
+                "description": "redirect to target as per configuration of the shortlink",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "default"
+                ],
+                "summary": "redirect to target",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "shortlink id",
+                        "name": "shortlink",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "300": {
+                        "description": "MultipleChoices",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "301": {
+                        "description": "MovedPermanently",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "302": {
+                        "description": "Found",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "303": {
+                        "description": "SeeOther",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "304": {
+                        "description": "NotModified",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "305": {
+                        "description": "UseProxy",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "307": {
+                        "description": "TemporaryRedirect",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "308": {
+                        "description": "PermanentRedirect",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "404": {
+                        "description": "NotFound",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "429": {
+                        "description": "TooManyRequests",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "500": {
+                        "description": "InternalServerError",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controller.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Detail is a human-readable explanation specific to this occurrence of the problem",
+                    "type": "string"
+                },
+                "instance": {
+                    "description": "Instance is the request path which caused the problem",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is the HTTP status code of the response",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Title is a short, human-readable summary of the problem type",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Type is a URI reference identifying the problem type",
+                    "type": "string"
+                }
+            }
+        },
+        "controller.ShortLink": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "spec": {
+                    "$ref": "#/definitions/v1alpha1.ShortLinkSpec"
+                },
+                "status": {
+                    "$ref": "#/definitions/v1alpha1.ShortLinkStatus"
+                }
+            }
+        },
+        "controller.ShortLinkRequest": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "description": "RedirectAfter specifies after how many seconds to redirect (Default=3)\n+kubebuilder:default:=0\n+kubebuilder:validation:Minimum=0\n+kubebuilder:validation:Maximum=99",
+                    "type": "integer"
+                },
+                "aliases": {
+                    "description": "Aliases are additional slugs the shortlink is served at. Invocations of aliases are counted for the\nshortlink, with a breakdown per alias in the status\n+kubebuilder:validation:Optional",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "description": "Code is the URL Code used for the redirection.\nleave on default (307) when using the HTML behavior. However, if you whish to use a HTTP 3xx redirect, set to the appropriate 3xx status code\n+kubebuilder:validation:Enum=200;300;301;302;303;304;305;307;308\n+kubebuilder:default:=307",
+                    "type": "integer",
+                    "enum": [
+                        200,
+                        300,
+                        301,
+                        302,
+                        303,
+                        304,
+                        305,
+                        307,
+                        308
+                    ]
+                },
+                "description": {
+                    "description": "Description is a human readable explanation of what the shortlink is used for\n+kubebuilder:validation:Optional\n+kubebuilder:validation:MaxLength=1024",
+                    "type": "string"
+                },
+                "disableHealthCheck": {
+                    "description": "DisableHealthCheck opts the shortlink out of the periodic health check of its target\n+kubebuilder:validation:Optional",
+                    "type": "boolean"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt is the time after which the shortlink no longer redirects\n+kubebuilder:validation:Optional\n+kubebuilder:validation:Format:date-time",
+                    "type": "string"
+                },
+                "labels": {
+                    "description": "Labels are set as Kubernetes labels on the shortlink",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "openGraph": {
+                    "description": "OpenGraph is the metadata shown in the preview of the shortlink when it is shared in a chat or social network.\nFields which aren't set are taken from the metadata of the target, if fetching is enabled\n+kubebuilder:validation:Optional",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1alpha1.OpenGraphSpec"
+                        }
+                    ]
                 },
                 "owner": {
                     "description": "Owner is the GitHub user name which created the shortlink\n+kubebuilder:validation:Required",
@@ -465,6 +1052,301 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "queryParameterPolicy": {
+                    "description": "QueryParameterPolicy selects whether QueryParameters and the defaults of the namespace Override parameters\nalready in the target, or KeepExisting ones. Defaults to KeepExisting\n+kubebuilder:validation:Enum=Override;KeepExisting\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "queryParameters": {
+                    "description": "QueryParameters are added to the query of the target, e.g. utm_source and utm_campaign. They take precedence\nover the defaults of the namespace\n+kubebuilder:validation:Optional",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "slug": {
+                    "description": "Slug is the path the shortlink is served at. Slugs are case-insensitive and may contain characters\nwhich are not allowed in the name of the ShortLink, e.g. upper case, unicode or dots. Defaults to the name\n+kubebuilder:validation:Optional\n+kubebuilder:validation:MaxLength=253",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags are free-form keywords to group shortlinks, e.g. by event or team\n+kubebuilder:validation:Optional",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "target": {
+                    "description": "Target specifies the target to which we will redirect\n+kubebuilder:validation:Required\n+kubebuilder:validation:MinLength=1",
+                    "type": "string"
+                }
+            }
+        },
+        "k8s_io_apimachinery_pkg_apis_meta_v1.ConditionStatus": {
+            "type": "string",
+            "enum": [
+                "True",
+                "False",
+                "Unknown"
+            ],
+            "x-enum-varnames": [
+                "ConditionTrue",
+                "ConditionFalse",
+                "ConditionUnknown"
+            ]
+        },
+        "model.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "Actor is the GitHub user who performed the operation",
+                    "type": "string"
+                },
+                "changes": {
+                    "description": "Changes are the fields of the ShortLinkSpec modified by the operation",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SpecChange"
+                    }
+                },
+                "operation": {
+                    "description": "Operation is one of AuditOperationCreate, AuditOperationUpdate, AuditOperationDelete or AuditOperationRollback",
+                    "type": "string"
+                },
+                "sourceIP": {
+                    "description": "SourceIP is the IP address the request originated from",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "description": "Timestamp is the time the operation was performed",
+                    "type": "string"
+                }
+            }
+        },
+        "model.Revision": {
+            "type": "object",
+            "properties": {
+                "changedBy": {
+                    "description": "ChangedBy is the GitHub user who created the revision",
+                    "type": "string"
+                },
+                "revision": {
+                    "description": "Revision is the number of the revision, starting at 1. The highest revision is the current spec",
+                    "type": "integer"
+                },
+                "spec": {
+                    "description": "Spec is the spec of the ShortLink at this revision",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1alpha1.ShortLinkSpec"
+                        }
+                    ]
+                },
+                "timestamp": {
+                    "description": "Timestamp is the time the revision was created",
+                    "type": "string"
+                }
+            }
+        },
+        "model.SpecChange": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "description": "After is the value after the operation. nil if the field was removed"
+                },
+                "before": {
+                    "description": "Before is the value before the operation. nil if the field was not set"
+                },
+                "field": {
+                    "description": "Field is the JSON name of the changed field, e.g. \"target\"",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.Condition": {
+            "type": "object",
+            "properties": {
+                "lastTransitionTime": {
+                    "description": "lastTransitionTime is the last time the condition transitioned from one status to another.\nThis should be when the underlying condition changed.  If that is not known, then using the time when the API field changed is acceptable.\n+required\n+kubebuilder:validation:Required\n+kubebuilder:validation:Type=string\n+kubebuilder:validation:Format=date-time",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "message is a human readable message indicating details about the transition.\nThis may be an empty string.\n+required\n+kubebuilder:validation:Required\n+kubebuilder:validation:MaxLength=32768",
+                    "type": "string"
+                },
+                "observedGeneration": {
+                    "description": "observedGeneration represents the .metadata.generation that the condition was set based upon.\nFor instance, if .metadata.generation is currently 12, but the .status.conditions[x].observedGeneration is 9, the condition is out of date\nwith respect to the current state of the instance.\n+optional\n+kubebuilder:validation:Minimum=0",
+                    "type": "integer"
+                },
+                "reason": {
+                    "description": "reason contains a programmatic identifier indicating the reason for the condition's last transition.\nProducers of specific condition types may define expected values and meanings for this field,\nand whether the values are considered a guaranteed API.\nThe value should be a CamelCase string.\nThis field may not be empty.\n+required\n+kubebuilder:validation:Required\n+kubebuilder:validation:MaxLength=1024\n+kubebuilder:validation:MinLength=1\n+kubebuilder:validation:Pattern=` + "`" + `^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$` + "`" + `",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "status of the condition, one of True, False, Unknown.\n+required\n+kubebuilder:validation:Required\n+kubebuilder:validation:Enum=True;False;Unknown",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/k8s_io_apimachinery_pkg_apis_meta_v1.ConditionStatus"
+                        }
+                    ]
+                },
+                "type": {
+                    "description": "type of condition in CamelCase or in foo.example.com/CamelCase.\n---\nMany .condition.type values are consistent across resources like Available, but because arbitrary conditions can be\nuseful (see .node.status.conditions), the ability to deconflict is important.\nThe regex it matches is (dns1123SubdomainFmt/)?(qualifiedNameFmt)\n+required\n+kubebuilder:validation:Required\n+kubebuilder:validation:Pattern=` + "`" + `^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])$` + "`" + `\n+kubebuilder:validation:MaxLength=316",
+                    "type": "string"
+                }
+            }
+        },
+        "v1alpha1.HealthCheckStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error is the reason the target couldn't be reached\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "lastChecked": {
+                    "description": "LastChecked is the time of the last health check\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "lastStatusCode": {
+                    "description": "LastStatusCode is the HTTP status code returned by the target. 0 if the target couldn't be reached\n+kubebuilder:validation:Optional",
+                    "type": "integer"
+                },
+                "latencyMilliseconds": {
+                    "description": "LatencyMilliseconds is the time it took the target to respond\n+kubebuilder:validation:Optional",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1alpha1.OpenGraphSpec": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "Description is the description of the preview (og:description)\n+kubebuilder:validation:Optional\n+kubebuilder:validation:MaxLength=1024",
+                    "type": "string"
+                },
+                "fetch": {
+                    "description": "Fetch enables fetching the metadata which isn't set here from the target, if the urlshortener fetches metadata\nat all. Only publicly routable targets are fetched\n+kubebuilder:validation:Optional",
+                    "type": "boolean"
+                },
+                "image": {
+                    "description": "Image is the URL of the image of the preview (og:image)\n+kubebuilder:validation:Optional\n+kubebuilder:validation:Pattern=` + "`" + `^https?://` + "`" + `",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "Title is the title of the preview (og:title)\n+kubebuilder:validation:Optional\n+kubebuilder:validation:MaxLength=256",
+                    "type": "string"
+                }
+            }
+        },
+        "v1alpha1.OpenGraphStatus": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "Description is the og:description of the target, or the description of the page if it has none\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error is the reason the metadata couldn't be fetched\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "image": {
+                    "description": "Image is the absolute URL of the og:image of the target\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "lastFetched": {
+                    "description": "LastFetched is the time the metadata was last fetched\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "target": {
+                    "description": "Target is the target the metadata was fetched from\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "Title is the og:title of the target, or the title of the page if it has none\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                }
+            }
+        },
+        "v1alpha1.ShortLinkSpec": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "description": "RedirectAfter specifies after how many seconds to redirect (Default=3)\n+kubebuilder:default:=0\n+kubebuilder:validation:Minimum=0\n+kubebuilder:validation:Maximum=99",
+                    "type": "integer"
+                },
+                "aliases": {
+                    "description": "Aliases are additional slugs the shortlink is served at. Invocations of aliases are counted for the\nshortlink, with a breakdown per alias in the status\n+kubebuilder:validation:Optional",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "description": "Code is the URL Code used for the redirection.\nleave on default (307) when using the HTML behavior. However, if you whish to use a HTTP 3xx redirect, set to the appropriate 3xx status code\n+kubebuilder:validation:Enum=200;300;301;302;303;304;305;307;308\n+kubebuilder:default:=307",
+                    "type": "integer",
+                    "enum": [
+                        200,
+                        300,
+                        301,
+                        302,
+                        303,
+                        304,
+                        305,
+                        307,
+                        308
+                    ]
+                },
+                "description": {
+                    "description": "Description is a human readable explanation of what the shortlink is used for\n+kubebuilder:validation:Optional\n+kubebuilder:validation:MaxLength=1024",
+                    "type": "string"
+                },
+                "disableHealthCheck": {
+                    "description": "DisableHealthCheck opts the shortlink out of the periodic health check of its target\n+kubebuilder:validation:Optional",
+                    "type": "boolean"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt is the time after which the shortlink no longer redirects\n+kubebuilder:validation:Optional\n+kubebuilder:validation:Format:date-time",
+                    "type": "string"
+                },
+                "openGraph": {
+                    "description": "OpenGraph is the metadata shown in the preview of the shortlink when it is shared in a chat or social network.\nFields which aren't set are taken from the metadata of the target, if fetching is enabled\n+kubebuilder:validation:Optional",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1alpha1.OpenGraphSpec"
+                        }
+                    ]
+                },
+                "owner": {
+                    "description": "Owner is the GitHub user name which created the shortlink\n+kubebuilder:validation:Required",
+                    "type": "string"
+                },
+                "owners": {
+                    "description": "Co-Owners are the GitHub user name which can also administrate this shortlink\n+kubebuilder:validation:Optional",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "queryParameterPolicy": {
+                    "description": "QueryParameterPolicy selects whether QueryParameters and the defaults of the namespace Override parameters\nalready in the target, or KeepExisting ones. Defaults to KeepExisting\n+kubebuilder:validation:Enum=Override;KeepExisting\n+kubebuilder:validation:Optional",
+                    "type": "string"
+                },
+                "queryParameters": {
+                    "description": "QueryParameters are added to the query of the target, e.g. utm_source and utm_campaign. They take precedence\nover the defaults of the namespace\n+kubebuilder:validation:Optional",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "slug": {
+                    "description": "Slug is the path the shortlink is served at. Slugs are case-insensitive and may contain characters\nwhich are not allowed in the name of the ShortLink, e.g. upper case, unicode or dots. Defaults to the name\n+kubebuilder:validation:Optional\n+kubebuilder:validation:MaxLength=253",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags are free-form keywords to group shortlinks, e.g. by event or team\n+kubebuilder:validation:Optional",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "target": {
                     "description": "Target specifies the target to which we will redirect\n+kubebuilder:validation:Required\n+kubebuilder:validation:MinLength=1",
                     "type": "string"
@@ -474,17 +1356,58 @@ const docTemplate = `{
         "v1alpha1.ShortLinkStatus": {
             "type": "object",
             "properties": {
+                "aliasCounts": {
+                    "description": "AliasCounts represents how often this ShortLink has been called by each of its aliases\n+kubebuilder:validation:Optional",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
                 "changedby": {
                     "description": "ChangedBy indicates who (GitHub User) changed the Shortlink last\n+kubebuilder:validation:Optional",
                     "type": "string"
                 },
+                "conditions": {
+                    "description": "Conditions represent the latest available observations of the ShortLink\n+kubebuilder:validation:Optional\n+listType=map\n+listMapKey=type",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.Condition"
+                    }
+                },
                 "count": {
-                    "description": "Count represents how often this ShortLink has been called\n+kubebuilder:default:=0\n+kubebuilder:validation:Minimum=0",
+                    "description": "Count represents how often this ShortLink has been called, including its aliases\n+kubebuilder:default:=0\n+kubebuilder:validation:Minimum=0",
                     "type": "integer"
                 },
+                "crawlerCounts": {
+                    "description": "CrawlerCounts represents how often this ShortLink has been requested by each kind of crawler, e.g. chat apps\nunfurling the link or security scanners. Requests of crawlers aren't included in Count\n+kubebuilder:validation:Optional",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "healthCheck": {
+                    "description": "HealthCheck is the result of the last health check of the target\n+kubebuilder:validation:Optional",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1alpha1.HealthCheckStatus"
+                        }
+                    ]
+                },
                 "lastmodified": {
                     "description": "LastModified is a date-time when the ShortLink was last modified\n+kubebuilder:validation:Format:date-time\n+kubebuilder:validation:Optional",
                     "type": "string"
+                },
+                "observedGeneration": {
+                    "description": "ObservedGeneration is the generation of the spec the status was computed for\n+kubebuilder:validation:Optional",
+                    "type": "integer"
+                },
+                "openGraph": {
+                    "description": "OpenGraph is the Open Graph metadata fetched from the target\n+kubebuilder:validation:Optional",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1alpha1.OpenGraphStatus"
+                        }
+                    ]
                 }
             }
         }
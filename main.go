@@ -19,9 +19,12 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -40,11 +43,16 @@ import (
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 
 	v1alpha1 "github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/api/v1beta1"
 	"github.com/cedi/urlshortener/controllers"
 	shortlinkClient "github.com/cedi/urlshortener/pkg/client"
 	apiController "github.com/cedi/urlshortener/pkg/controller"
+	"github.com/cedi/urlshortener/pkg/healthcheck"
 	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/cedi/urlshortener/pkg/opengraph"
+	redirectpkg "github.com/cedi/urlshortener/pkg/redirect"
 	"github.com/cedi/urlshortener/pkg/router"
+	"github.com/cedi/urlshortener/pkg/slug"
 
 	"github.com/pkg/errors"
 	//+kubebuilder:scaffold:imports
@@ -60,6 +68,7 @@ func init() {
 	utilRuntime.Must(clientGoScheme.AddToScheme(scheme))
 
 	utilRuntime.Must(v1alpha1.AddToScheme(scheme))
+	utilRuntime.Must(v1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -80,12 +89,77 @@ func main() {
 	var bindAddr string
 	var namespaced bool
 	var debug bool
+	var revisionHistoryLimit int
+	var healthCheckInterval time.Duration
+	var healthCheckTimeout time.Duration
+	var healthCheckWorkers int
+	var healthCheckHostQPS float64
+	var openGraphInterval time.Duration
+	var openGraphTimeout time.Duration
+	var openGraphWorkers int
+	var defaultRedirectBackend string
+	var gatewayAPI bool
+	var certManager bool
+	var conversionWebhook bool
+	var legacyMigration bool
+	var legacyMigrationOwner string
+	var reservedSlugs string
+	var gateway string
+	var ingressRenderers string
+	var ingressClassRenderers string
+	var nativeRedirectService string
+	var nativeRedirectServicePort int
+	var shortenerHosts string
+	var maxShortlinksPerUser int
+	var rateLimits apiController.RateLimits
+	var trustedProxies string
+	var crawlerPreview bool
+	var queryDefaultsConfigMap string
+	var uiConfig apiController.UIConfig
+	var uiScopes string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":9110", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":9081", "The address the probe endpoint binds to.")
 	flag.StringVar(&bindAddr, "bind-address", ":8443", "The address the service binds to.")
 	flag.BoolVar(&namespaced, "namespaced", true, "Restrict the urlshortener to only list resources in the current namespace")
 	flag.BoolVar(&debug, "debug", false, "Turn on debug logging")
+	flag.DurationVar(&healthCheckInterval, "health-check-interval", 0, "How often the target of every shortlink is checked, e.g. 1h. 0 disables health checks")
+	flag.IntVar(&healthCheckWorkers, "health-check-workers", 4, "The number of health checks running at once")
+	flag.DurationVar(&healthCheckTimeout, "health-check-timeout", 10*time.Second, "The timeout of a single health check")
+	flag.Float64Var(&healthCheckHostQPS, "health-check-host-qps", 1, "The maximum number of health checks per second against a single host")
+	flag.DurationVar(&openGraphInterval, "open-graph-fetch-interval", 0, "How often the Open Graph metadata of the target of shortlinks opting in with openGraph.fetch is fetched for link previews. Only publicly routable targets are fetched. 0 disables fetching")
+	flag.DurationVar(&openGraphTimeout, "open-graph-fetch-timeout", 10*time.Second, "The timeout of fetching the Open Graph metadata of a single target")
+	flag.IntVar(&openGraphWorkers, "open-graph-fetch-workers", 4, "The number of Open Graph fetches running at once")
+	flag.StringVar(&defaultRedirectBackend, "default-redirect-backend", v1alpha1.RedirectBackendIngress, "The backend used for Redirects which don't specify one, either Ingress or HTTPRoute")
+	flag.BoolVar(&gatewayAPI, "enable-gateway-api", false, "Enable the HTTPRoute backend for Redirects. Requires the Gateway API CRDs to be installed")
+	flag.BoolVar(&certManager, "enable-cert-manager", false, "Enable requesting certificates for Redirects from cert-manager. Requires the cert-manager CRDs to be installed")
+	flag.BoolVar(&conversionWebhook, "enable-conversion-webhook", false, "Serve the webhook converting ShortLinks and Redirects between v1alpha1 and v1beta1. Requires a serving certificate in the webhook cert dir")
+	flag.BoolVar(&legacyMigration, "enable-legacy-migration", false, "Migrate ShortLinks and Redirects from the legacy urlshortener.av0.de API group. Requires the legacy CRDs to be installed")
+	flag.StringVar(&legacyMigrationOwner, "legacy-migration-owner", "", "The owner of migrated ShortLinks, as legacy ShortLinks have no owner")
+	flag.StringVar(&reservedSlugs, "reserved-slugs", "", fmt.Sprintf("Comma separated list of slugs ShortLinks can't be created with, in addition to %v", slug.DefaultReserved))
+	flag.StringVar(&gateway, "gateway", "", "The Gateway HTTPRoutes are attached to if the Redirect doesn't specify one, as namespace/name[/sectionName]")
+	flag.StringVar(&ingressRenderers, "ingress-renderers", redirectpkg.RendererNginx, fmt.Sprintf("Comma separated list of enabled renderers for the Ingress backend of Redirects, out of %v. Requires the CRDs of the ingress controllers to be installed", redirectpkg.AvailableRenderers()))
+	flag.StringVar(&ingressClassRenderers, "ingress-class-renderers", "", "Comma separated list of class=renderer pairs selecting the renderer of an ingress class. Classes not listed use the renderer of the same name if enabled, nginx otherwise")
+	flag.StringVar(&nativeRedirectService, "native-redirect-service", "urlshortener", "The name of the Service of the urlshortener native Redirects are routed to")
+	flag.StringVar(&shortenerHosts, "shortener-hosts", "", "Comma separated list of hosts the urlshortener itself is served at, in addition to the host of --public-url. Redirects can't use them as source")
+	flag.IntVar(&nativeRedirectServicePort, "native-redirect-service-port", 8123, "The port of the Service of the urlshortener native Redirects are routed to")
+	flag.IntVar(&revisionHistoryLimit, "revision-history-limit", shortlinkClient.DefaultRevisionHistoryLimit, "The number of spec revisions kept per shortlink")
+	flag.IntVar(&maxShortlinksPerUser, "max-shortlinks-per-user", 0, "The maximum number of shortlinks a user may own. Concurrent requests can exceed this soft limit by a few shortlinks. 0 disables the quota")
+	flag.Float64Var(&rateLimits.RedirectPerIP, "redirect-rate-limit", 0, "The maximum number of shortlink requests per second and client IP, e.g. 10. Requires --trusted-proxies behind an ingress controller, as all clients share the IP of the proxy otherwise. 0 disables the limit")
+	flag.Float64Var(&rateLimits.APIPerIP, "api-rate-limit", 0, "The maximum number of API requests per second and client IP, e.g. 5. Requires --trusted-proxies behind an ingress controller, as all clients share the IP of the proxy otherwise. 0 disables the limit")
+	flag.Float64Var(&rateLimits.APIPerUser, "api-user-rate-limit", 2, "The maximum number of API requests per second and user. 0 disables the limit")
+	flag.IntVar(&rateLimits.Burst, "rate-limit-burst", 20, "The number of requests a client may send at once before the rate limits apply")
+	flag.StringVar(&queryDefaultsConfigMap, "query-parameter-defaults-configmap", "urlshortener-query-parameters", "The name of the ConfigMap holding the default query parameters added to the targets of the ShortLinks of its namespace. Empty disables the defaults")
+	flag.StringVar(&uiConfig.ClientID, "ui-oauth-client-id", "", "The OAuth client ID of the web UI. The web UI under /ui is enabled if set. The client secret is read from the UI_OAUTH_CLIENT_SECRET environment variable")
+	flag.StringVar(&uiConfig.AuthURL, "ui-oauth-auth-url", "", "The authorization endpoint of the identity provider of the web UI. Defaults to GitHub")
+	flag.StringVar(&uiConfig.TokenURL, "ui-oauth-token-url", "", "The token endpoint of the identity provider of the web UI. Defaults to GitHub")
+	flag.StringVar(&uiConfig.UserInfoURL, "ui-oauth-userinfo-url", apiController.DefaultUIUserInfoURL, "The endpoint returning the login or preferred_username of the user logged into the web UI")
+	flag.StringVar(&uiConfig.UsernamePrefix, "ui-oauth-username-prefix", "", "The prefix of the usernames of the web UI users of an identity provider other than GitHub, e.g. sso:. Required with a custom --ui-oauth-userinfo-url, so that these users can't act as the GitHub user of the same name")
+	flag.StringVar(&uiScopes, "ui-oauth-scopes", "read:user", "Comma separated list of OAuth scopes requested by the web UI")
+	flag.StringVar(&uiConfig.PublicURL, "public-url", "", "The URL the urlshortener is served at, e.g. https://go.example.com. Required by the web UI, and by native Redirects unless --shortener-hosts is set")
+	flag.DurationVar(&uiConfig.SessionDuration, "ui-session-duration", 12*time.Hour, "How long a login to the web UI is valid. The session cookies are signed with the UI_SESSION_SECRET environment variable, which must be the same for all replicas")
+	flag.BoolVar(&crawlerPreview, "crawler-preview", false, "Serve link preview crawlers of chat apps and social networks a preview page of the shortlink instead of redirecting them")
+	flag.StringVar(&trustedProxies, "trusted-proxies", "", "Comma separated list of IPs and CIDRs of proxies whose X-Forwarded-For header is trusted to carry the client IP, e.g. the pod CIDR of the ingress controller. By default no proxy is trusted")
 
 	flag.Parse()
 
@@ -154,6 +228,7 @@ func main() {
 
 	sClient := shortlinkClient.NewShortlinkClient(
 		mgr.GetClient(),
+		mgr.GetAPIReader(),
 		tracer,
 	)
 
@@ -162,10 +237,32 @@ func main() {
 		tracer,
 	)
 
+	if err := shortlinkClient.IndexRedirectSource(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		span.RecordError(err)
+		otelzap.L().Sugar().Errorw("unable to index Redirects by source",
+			zap.Error(err),
+		)
+		os.Exit(1)
+	}
+
+	if err := shortlinkClient.IndexShortLinkSlug(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		span.RecordError(err)
+		otelzap.L().Sugar().Errorw("unable to index ShortLinks by slug",
+			zap.Error(err),
+		)
+		os.Exit(1)
+	}
+
 	shortlinkReconciler := controllers.NewShortLinkReconciler(
 		sClient,
 		mgr.GetScheme(),
 		tracer,
+		healthcheck.NewChecker(tracer, healthCheckTimeout, healthCheckHostQPS, 1),
+		healthCheckInterval,
+		healthCheckWorkers,
+		opengraph.NewFetcher(tracer, openGraphTimeout),
+		openGraphInterval,
+		openGraphWorkers,
 	)
 
 	if err = shortlinkReconciler.SetupWithManager(mgr); err != nil {
@@ -177,11 +274,68 @@ func main() {
 		os.Exit(1)
 	}
 
+	if defaultRedirectBackend != v1alpha1.RedirectBackendIngress && defaultRedirectBackend != v1alpha1.RedirectBackendHTTPRoute {
+		otelzap.L().Sugar().Errorw("invalid --default-redirect-backend, must be Ingress or HTTPRoute",
+			zap.String("backend", defaultRedirectBackend),
+		)
+		os.Exit(1)
+	}
+
+	redirectOptions := controllers.RedirectOptions{
+		DefaultBackend: defaultRedirectBackend,
+		GatewayAPI:     gatewayAPI,
+		CertManager:    certManager,
+	}
+
+	if gateway != "" {
+		gatewayRef, err := parseGatewayReference(gateway)
+		if err != nil {
+			span.RecordError(err)
+			otelzap.L().Sugar().Errorw("invalid --gateway",
+				zap.Error(err),
+			)
+			os.Exit(1)
+		}
+
+		redirectOptions.DefaultParentRefs = []v1alpha1.GatewayReference{*gatewayRef}
+	}
+
+	renderers, err := parseRenderers(ingressRenderers, ingressClassRenderers)
+	if err != nil {
+		span.RecordError(err)
+		otelzap.L().Sugar().Errorw("invalid --ingress-renderers or --ingress-class-renderers",
+			zap.Error(err),
+		)
+		os.Exit(1)
+	}
+
+	ownHosts, err := parseOwnHosts(shortenerHosts, uiConfig.PublicURL)
+	if err != nil {
+		span.RecordError(err)
+		otelzap.L().Sugar().Errorw("invalid --public-url",
+			zap.Error(err),
+		)
+		os.Exit(1)
+	}
+
+	if len(ownHosts) == 0 {
+		otelzap.L().Warn("neither --public-url nor --shortener-hosts is set, native Redirects are disabled")
+	}
+
+	redirectOptions.OwnHosts = ownHosts
+	redirectOptions.Renderers = renderers
+	redirectOptions.NativeBackend = redirectpkg.NativeBackend{
+		Name: nativeRedirectService,
+		Port: int32(nativeRedirectServicePort),
+	}
+
 	redirectReconciler := controllers.NewRedirectReconciler(
 		mgr.GetClient(),
 		rClient,
 		mgr.GetScheme(),
 		tracer,
+		mgr.GetEventRecorderFor("urlshortener"),
+		redirectOptions,
 	)
 
 	if err = redirectReconciler.SetupWithManager(mgr); err != nil {
@@ -192,8 +346,50 @@ func main() {
 		)
 		os.Exit(1)
 	}
+
+	if legacyMigration {
+		for _, kind := range []string{"ShortLink", "Redirect"} {
+			migrationReconciler := controllers.NewMigrationReconciler(
+				mgr.GetClient(),
+				tracer,
+				mgr.GetEventRecorderFor("urlshortener"),
+				kind,
+				legacyMigrationOwner,
+			)
+
+			if err = migrationReconciler.SetupWithManager(mgr); err != nil {
+				span.RecordError(err)
+				otelzap.L().Sugar().Errorw("unable to create controller",
+					zap.Error(err),
+					zap.String("controller", "Migration"),
+					zap.String("kind", kind),
+				)
+				os.Exit(1)
+			}
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	if conversionWebhook {
+		if err = (&v1beta1.ShortLink{}).SetupWebhookWithManager(mgr); err != nil {
+			span.RecordError(err)
+			otelzap.L().Sugar().Errorw("unable to create webhook",
+				zap.Error(err),
+				zap.String("webhook", "ShortLink"),
+			)
+			os.Exit(1)
+		}
+
+		if err = (&v1beta1.Redirect{}).SetupWebhookWithManager(mgr); err != nil {
+			span.RecordError(err)
+			otelzap.L().Sugar().Errorw("unable to create webhook",
+				zap.Error(err),
+				zap.String("webhook", "Redirect"),
+			)
+			os.Exit(1)
+		}
+	}
+
 	span.End()
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -222,17 +418,85 @@ func main() {
 		}
 	}()
 
+	auditClient := shortlinkClient.NewAuditClient(
+		mgr.GetClient(),
+		mgr.GetAPIReader(),
+		mgr.GetEventRecorderFor("urlshortener"),
+		tracer,
+	)
+
+	rateLimiter := apiController.NewRateLimiter(rateLimits)
+
 	shortlinkController := apiController.NewShortlinkController(
 		tracer,
 		sClient,
+		auditClient,
+		shortlinkClient.NewQueryDefaultsClient(mgr.GetAPIReader(), tracer, queryDefaultsConfigMap),
+		revisionHistoryLimit,
+		maxShortlinksPerUser,
+		rateLimiter,
+		parseReservedSlugs(reservedSlugs),
+		crawlerPreview,
 	)
 
+	// Native Redirects are only served from the namespace of the urlshortener, even if it isn't namespaced
+	nativeRedirectNamespace := namespace
+	if nativeRedirectNamespace == "" {
+		if namespaceByte, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+			nativeRedirectNamespace = string(namespaceByte)
+		} else {
+			otelzap.L().Sugar().Warnw("unable to read the namespace of the urlshortener, native Redirects are disabled",
+				zap.Error(err),
+			)
+		}
+	}
+
 	// Init Gin Framework
 	gin.SetMode(gin.ReleaseMode)
-	r, srv := router.NewGinGonicHTTPServer(bindAddr, serviceName)
+	redirectController := apiController.NewRedirectController(
+		tracer,
+		rClient,
+		rateLimiter,
+		nativeRedirectNamespace,
+		ownHosts,
+	)
+
+	r, srv := router.NewGinGonicHTTPServer(bindAddr, serviceName, redirectController)
+
+	if err := r.SetTrustedProxies(parseTrustedProxies(trustedProxies)); err != nil {
+		otelzap.L().Sugar().Errorw("invalid trusted proxies",
+			zap.Error(err),
+			zap.String("trustedProxies", trustedProxies),
+		)
+		os.Exit(1)
+	}
+
+	if trustedProxies == "" && (rateLimits.RedirectPerIP > 0 || rateLimits.APIPerIP > 0) {
+		otelzap.L().Warn("per client IP rate limits are enabled without --trusted-proxies, all clients behind a proxy share its limit")
+	}
 
 	otelzap.L().Info("Load API routes")
-	router.Load(r, shortlinkController)
+	router.Load(r, shortlinkController, rateLimiter)
+
+	if uiConfig.ClientID != "" {
+		uiConfig.ClientSecret = os.Getenv("UI_OAUTH_CLIENT_SECRET")
+		uiConfig.SessionSecret = []byte(os.Getenv("UI_SESSION_SECRET"))
+		uiConfig.Scopes = parseScopes(uiScopes)
+
+		if err := uiConfig.Validate(); err != nil {
+			otelzap.L().Sugar().Errorw("invalid web UI configuration, check --public-url and --ui-oauth-username-prefix",
+				zap.Error(err),
+			)
+			os.Exit(1)
+		}
+
+		if len(uiConfig.SessionSecret) == 0 {
+			otelzap.L().Warn("UI_SESSION_SECRET is not set, logins to the web UI are lost on restart and not shared between replicas")
+		}
+
+		otelzap.L().Info("Load UI routes")
+		router.LoadUI(r, apiController.NewUIController(tracer, shortlinkController, uiConfig), rateLimiter)
+	}
 
 	// run our gin server mgr in a separate go routine
 	go func() {
@@ -248,6 +512,51 @@ func main() {
 	otelzap.L().Info("Server exiting")
 }
 
+// parseGatewayReference parses a Gateway reference in the form namespace/name[/sectionName]
+func parseGatewayReference(ref string) (*v1alpha1.GatewayReference, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid Gateway reference %q, expected namespace/name[/sectionName]", ref)
+	}
+
+	gatewayRef := &v1alpha1.GatewayReference{
+		Namespace: parts[0],
+		Name:      parts[1],
+	}
+
+	if len(parts) == 3 {
+		gatewayRef.SectionName = parts[2]
+	}
+
+	return gatewayRef, nil
+}
+
+// parseRenderers parses the comma separated list of enabled renderers and class=renderer pairs
+func parseRenderers(enabled string, classes string) (*redirectpkg.Renderers, error) {
+	names := make([]string, 0)
+	for _, name := range strings.Split(enabled, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	classRenderers := make(map[string]string)
+	for _, pair := range strings.Split(classes, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		class, name, ok := strings.Cut(pair, "=")
+		if !ok || class == "" || name == "" {
+			return nil, fmt.Errorf("invalid ingress class renderer %q, expected class=renderer", pair)
+		}
+
+		classRenderers[class] = name
+	}
+
+	return redirectpkg.NewRenderers(names, classRenderers)
+}
+
 // handleShutdown waits for interrupt signal and then tries to gracefully
 // shutdown the server with a timeout of 5 seconds.
 func handleShutdown(srv *http.Server) {
@@ -279,3 +588,64 @@ func handleShutdown(srv *http.Server) {
 		os.Exit(1)
 	}
 }
+
+// parseReservedSlugs returns the default reserved slugs and the additional comma separated slugs
+func parseReservedSlugs(slugs string) []string {
+	reserved := append([]string{}, slug.DefaultReserved...)
+
+	for _, name := range strings.Split(slugs, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			reserved = append(reserved, name)
+		}
+	}
+
+	return reserved
+}
+
+// parseTrustedProxies parses a comma separated list of IPs and CIDRs. An empty list trusts no proxy
+func parseTrustedProxies(proxies string) []string {
+	trusted := []string{}
+
+	for _, proxy := range strings.Split(proxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trusted = append(trusted, proxy)
+		}
+	}
+
+	return trusted
+}
+
+// parseScopes parses a comma separated list of OAuth scopes
+func parseScopes(scopes string) []string {
+	parsed := []string{}
+
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			parsed = append(parsed, scope)
+		}
+	}
+
+	return parsed
+}
+
+// parseOwnHosts returns the comma separated hosts and the host of publicURL, which the urlshortener is served at
+func parseOwnHosts(hosts string, publicURL string) ([]string, error) {
+	own := []string{}
+
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			own = append(own, strings.ToLower(host))
+		}
+	}
+
+	if publicURL != "" {
+		u, err := url.Parse(publicURL)
+		if err != nil || u.Hostname() == "" {
+			return nil, fmt.Errorf("%q is not an absolute URL", publicURL)
+		}
+
+		own = append(own, strings.ToLower(u.Hostname()))
+	}
+
+	return own, nil
+}
@@ -0,0 +1,253 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	contentTypeApplicationJSON = "application/json"
+	defaultUserAgent           = "urlshortener-apiclient/1.0"
+)
+
+// Client is a client for the urlshortener REST API (/api/v1)
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	userAgent  string
+	retry      RetryPolicy
+}
+
+// RetryPolicy configures how often and how long the client waits before it retries a failed request
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the initial attempt. 0 disables retries
+	MaxRetries int
+
+	// MinBackoff is the wait time before the first retry
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the exponentially growing wait time between retries
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the RetryPolicy used when no other policy is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 250 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the *http.Client used to perform the requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets the GitHub token used to authenticate against the API
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithUserAgent overrides the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetryPolicy overrides the DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// New creates a new Client for the urlshortener reachable at baseURL (e.g. https://short.example.com)
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid base URL")
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		userAgent:  defaultUserAgent,
+		retry:      DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// request describes a single API call
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+}
+
+// do performs the request, retrying idempotent requests on transient failures,
+// and decodes a successful JSON response into out (if out is not nil)
+func (c *Client) do(ctx context.Context, req request, out any) (*http.Response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if raw, ok := req.body.([]byte); ok {
+			body = raw
+		} else if body, err = json.Marshal(req.body); err != nil {
+			return nil, errors.Wrap(err, "Failed to marshal request body")
+		}
+	}
+
+	u := *c.baseURL
+	u.Path = u.Path + req.path
+	u.RawQuery = req.query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		resp, respBody, err := c.roundTrip(ctx, req, u.String(), body)
+
+		if !c.shouldRetry(req.method, attempt, resp, err) {
+			if err != nil {
+				return nil, err
+			}
+
+			if resp.StatusCode >= http.StatusBadRequest {
+				return resp, newAPIError(resp, respBody)
+			}
+
+			if out != nil && len(respBody) > 0 {
+				if err := json.Unmarshal(respBody, out); err != nil {
+					return resp, errors.Wrap(err, "Failed to unmarshal response body")
+				}
+			}
+
+			return resp, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff(attempt, resp)):
+		}
+	}
+}
+
+// roundTrip performs a single attempt of the request and reads the entire response body
+func (c *Client) roundTrip(ctx context.Context, req request, url string, body []byte) (*http.Response, []byte, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, url, bodyReader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to build request")
+	}
+
+	for key, values := range req.header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+
+	httpReq.Header.Set("Accept", contentTypeApplicationJSON+", application/problem+json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+
+	if body != nil && httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", contentTypeApplicationJSON)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "%s %s failed", req.method, req.path)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, errors.Wrap(err, "Failed to read response body")
+	}
+
+	return resp, respBody, nil
+}
+
+// shouldRetry decides if a request is retried. Only idempotent requests are retried,
+// and only on network errors or on status codes indicating a transient failure
+func (c *Client) shouldRetry(method string, attempt int, resp *http.Response, err error) bool {
+	if attempt >= c.retry.MaxRetries {
+		return false
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// backoff returns the time to wait before the next attempt. A Retry-After header sent
+// by the server takes precedence over the exponential backoff
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	wait := c.retry.MinBackoff << attempt
+	if wait <= 0 || wait > c.retry.MaxBackoff {
+		wait = c.retry.MaxBackoff
+	}
+
+	// add up to 20% jitter so that many clients don't retry in lockstep
+	if jitter := int64(wait) / 5; jitter > 0 {
+		wait += time.Duration(rand.Int63n(jitter))
+	}
+
+	return wait
+}
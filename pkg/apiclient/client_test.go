@@ -0,0 +1,314 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+)
+
+// noRetries keeps tests of failing requests fast
+var noRetries = WithRetryPolicy(RetryPolicy{})
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := New(server.URL, opts...)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	return client
+}
+
+func TestAuthorizationHeader(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+
+		if got := r.Header.Get("User-Agent"); got != "test-agent" {
+			t.Errorf("User-Agent = %q, want %q", got, "test-agent")
+		}
+
+		w.Header().Set("ETag", `"1"`)
+		_ = json.NewEncoder(w).Encode(ShortLink{Name: "foo"})
+	}, WithToken("secret"), WithUserAgent("test-agent"))
+
+	shortlink, err := client.GetShortLink(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("GetShortLink() failed: %v", err)
+	}
+
+	if shortlink.Name != "foo" || shortlink.ETag != `"1"` {
+		t.Errorf("GetShortLink() = %+v, want name foo with ETag \"1\"", shortlink)
+	}
+}
+
+func TestNoAuthorizationHeaderWithoutToken(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Header["Authorization"]; ok {
+			t.Error("Authorization header sent without token")
+		}
+
+		_, _ = w.Write([]byte("[]"))
+	})
+
+	if _, err := client.ListShortLinks(context.Background(), nil); err != nil {
+		t.Fatalf("ListShortLinks() failed: %v", err)
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		message     string
+		check       func(error) bool
+	}{
+		{
+			name:        "problem",
+			status:      http.StatusNotFound,
+			contentType: "application/problem+json",
+			body:        `{"type":"about:blank","title":"Not Found","status":404,"detail":"shortlink foo not found"}`,
+			message:     "shortlink foo not found",
+			check:       IsNotFound,
+		},
+		{
+			name:        "problem without detail",
+			status:      http.StatusConflict,
+			contentType: "application/problem+json",
+			body:        `{"type":"about:blank","title":"Conflict","status":409}`,
+			message:     "Conflict",
+			check:       IsConflict,
+		},
+		{
+			name:        "plain text",
+			status:      http.StatusForbidden,
+			contentType: "text/plain",
+			body:        "not allowed\n",
+			message:     "not allowed",
+			check:       IsForbidden,
+		},
+		{
+			name:    "empty body",
+			status:  http.StatusPreconditionFailed,
+			message: http.StatusText(http.StatusPreconditionFailed),
+			check:   IsPreconditionFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if test.contentType != "" {
+					w.Header().Set("Content-Type", test.contentType)
+				}
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			}, noRetries)
+
+			_, err := client.GetShortLink(context.Background(), "foo")
+
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				t.Fatalf("expected *APIError, got %T: %v", err, err)
+			}
+
+			if apiErr.StatusCode != test.status || apiErr.Message != test.message {
+				t.Errorf("got %d %q, want %d %q", apiErr.StatusCode, apiErr.Message, test.status, test.message)
+			}
+
+			if !test.check(err) {
+				t.Errorf("status check of %v failed", err)
+			}
+		})
+	}
+}
+
+func TestListAllShortLinksFollowsContinue(t *testing.T) {
+	pages := map[string]struct {
+		names []string
+		next  string
+	}{
+		"":   {names: []string{"a", "b"}, next: "p2"},
+		"p2": {names: []string{"c", "d"}, next: "p3"},
+		"p3": {names: []string{"e"}},
+	}
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("limit"); got != "2" {
+			t.Errorf("limit = %q, want 2", got)
+		}
+
+		if got := r.URL.Query().Get("owner"); got != "alice" {
+			t.Errorf("owner = %q, want alice", got)
+		}
+
+		page, ok := pages[r.URL.Query().Get("continue")]
+		if !ok {
+			t.Fatalf("unexpected continue token %q", r.URL.Query().Get("continue"))
+		}
+
+		items := make([]ShortLink, 0, len(page.names))
+		for _, name := range page.names {
+			items = append(items, ShortLink{Name: name})
+		}
+
+		if page.next != "" {
+			w.Header().Set(ContinueHeader, page.next)
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	})
+
+	shortlinks, err := client.ListAllShortLinks(context.Background(), &ListOptions{Limit: 2, Owner: "alice"})
+	if err != nil {
+		t.Fatalf("ListAllShortLinks() failed: %v", err)
+	}
+
+	names := make([]string, 0, len(shortlinks))
+	for _, shortlink := range shortlinks {
+		names = append(names, shortlink.Name)
+	}
+
+	if want := []string{"a", "b", "c", "d", "e"}; !equal(names, want) {
+		t.Errorf("ListAllShortLinks() = %v, want %v", names, want)
+	}
+}
+
+func TestListAllShortLinksWithoutPagination(t *testing.T) {
+	var requests int32
+
+	// Servers which don't paginate ignore the limit and return everything without a continue token
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_ = json.NewEncoder(w).Encode([]ShortLink{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	})
+
+	shortlinks, err := client.ListAllShortLinks(context.Background(), &ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListAllShortLinks() failed: %v", err)
+	}
+
+	if len(shortlinks) != 3 || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("got %d shortlinks in %d requests, want 3 in 1", len(shortlinks), requests)
+	}
+}
+
+func TestListAllShortLinksStopsOnRepeatedContinue(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ContinueHeader, "same")
+		_ = json.NewEncoder(w).Encode([]ShortLink{{Name: "a"}})
+	})
+
+	if _, err := client.ListAllShortLinks(context.Background(), nil); err == nil {
+		t.Fatal("expected an error for a server returning the same continue token again")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var requests int32
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(ShortLink{Name: "foo"})
+	}, WithRetryPolicy(RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}))
+
+	if _, err := client.GetShortLink(context.Background(), "foo"); err != nil {
+		t.Fatalf("GetShortLink() failed: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestNoRetryOfPost(t *testing.T) {
+	var requests int32
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithRetryPolicy(RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}))
+
+	_, err := client.CreateShortLink(context.Background(), "foo", v1alpha1.ShortLinkSpec{Target: "https://example.com"}, nil)
+	if err == nil {
+		t.Fatal("expected CreateShortLink() to fail")
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestUpdateSendsIfMatch(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+
+		if got := r.Header.Get("If-Match"); got != `"42"` {
+			t.Errorf("If-Match = %q, want %q", got, `"42"`)
+		}
+
+		w.Header().Set("ETag", `"43"`)
+		_ = json.NewEncoder(w).Encode(ShortLink{Name: "foo"})
+	})
+
+	shortlink, err := client.UpdateShortLink(context.Background(), "foo", v1alpha1.ShortLinkSpec{}, &UpdateOptions{IfMatch: `"42"`})
+	if err != nil {
+		t.Fatalf("UpdateShortLink() failed: %v", err)
+	}
+
+	if shortlink.ETag != `"43"` {
+		t.Errorf("ETag = %q, want %q", shortlink.ETag, `"43"`)
+	}
+}
+
+func TestRollbackQuery(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/shortlink/foo/rollback" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+
+		if got := r.URL.Query().Get("revision"); got != strconv.Itoa(3) {
+			t.Errorf("revision = %q, want 3", got)
+		}
+
+		_ = json.NewEncoder(w).Encode(ShortLink{Name: "foo"})
+	})
+
+	if _, err := client.RollbackShortLink(context.Background(), "foo", 3, nil); err != nil {
+		t.Fatalf("RollbackShortLink() failed: %v", err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// APIError is returned for every response of the urlshortener API with a status code >= 400
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Title is the short summary of the problem type returned by the API
+	Title string
+
+	// Message is the error message returned by the API
+	Message string
+}
+
+// problem mirrors controller.Problem, the RFC 7807 error body returned by the API
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+	}
+
+	p := problem{}
+	if err := json.Unmarshal(body, &p); err == nil && (p.Detail != "" || p.Title != "") {
+		apiErr.Title = p.Title
+		apiErr.Message = p.Detail
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = apiErr.Title
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("urlshortener API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound returns true if err is an APIError with status code 404
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsUnauthorized returns true if err is an APIError with status code 401
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+// IsForbidden returns true if err is an APIError with status code 403
+func IsForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden)
+}
+
+// IsConflict returns true if err is an APIError with status code 409
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+// IsPreconditionFailed returns true if err is an APIError with status code 412
+func IsPreconditionFailed(err error) bool {
+	return hasStatusCode(err, http.StatusPreconditionFailed)
+}
+
+// IsTooManyRequests returns true if err is an APIError with status code 429
+func IsTooManyRequests(err error) bool {
+	return hasStatusCode(err, http.StatusTooManyRequests)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
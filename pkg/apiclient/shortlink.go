@@ -0,0 +1,310 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/model"
+)
+
+// ContinueHeader is the response header carrying the token to fetch the next page of a list.
+// Servers which don't paginate lists don't send it and return all ShortLinks at once
+const ContinueHeader = "X-Continue"
+
+// PatchType is the content type of a patch passed to PatchShortLink
+type PatchType string
+
+const (
+	// MergePatchType is a JSON Merge Patch (RFC 7386) of the ShortLinkSpec
+	MergePatchType PatchType = "application/merge-patch+json"
+
+	// JSONPatchType is a JSON Patch (RFC 6902) of the ShortLinkSpec
+	JSONPatchType PatchType = "application/json-patch+json"
+)
+
+// ShortLink is the representation of a ShortLink returned by the API
+type ShortLink struct {
+	Name   string                   `json:"name"`
+	Labels map[string]string        `json:"labels,omitempty"`
+	Spec   v1alpha1.ShortLinkSpec   `json:"spec,omitempty"`
+	Status v1alpha1.ShortLinkStatus `json:"status,omitempty"`
+
+	// ETag identifies the version of the ShortLink. Pass it as UpdateOptions.IfMatch
+	// to only modify the ShortLink if nobody else changed it in the meantime
+	ETag string `json:"-"`
+}
+
+// shortlinkRequest mirrors controller.ShortLinkRequest, the body of create and update requests
+type shortlinkRequest struct {
+	v1alpha1.ShortLinkSpec
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// CreateOptions controls CreateShortLink
+type CreateOptions struct {
+	// Labels are set as Kubernetes labels on the ShortLink
+	Labels map[string]string
+}
+
+// UpdateOptions controls UpdateShortLink and PatchShortLink
+type UpdateOptions struct {
+	// IfMatch is the ETag the ShortLink must have for the update to succeed.
+	// If it doesn't match, the API returns 412 Precondition Failed
+	IfMatch string
+
+	// Labels replace the Kubernetes labels of the ShortLink. nil keeps the existing labels.
+	// Ignored by PatchShortLink, which patches the labels as part of the patch document
+	Labels map[string]string
+}
+
+func (o *UpdateOptions) header() http.Header {
+	header := http.Header{}
+	if o != nil && o.IfMatch != "" {
+		header.Set("If-Match", o.IfMatch)
+	}
+
+	return header
+}
+
+// ListOptions controls the page of ShortLinks returned by ListShortLinks
+type ListOptions struct {
+	// Limit is the maximum number of ShortLinks returned. 0 lets the server decide
+	Limit int
+
+	// Continue is the token returned by a previous call to fetch the next page
+	Continue string
+
+	// Owner only returns ShortLinks owned by this user
+	Owner string
+
+	// Tags only returns ShortLinks tagged with all of the given tags
+	Tags []string
+
+	// Domain only returns ShortLinks whose target is on this domain or one of its subdomains
+	Domain string
+
+	// LabelSelector is a Kubernetes label selector, e.g. "team=platform"
+	LabelSelector string
+
+	// CreatedAfter and CreatedBefore restrict the creation time of the ShortLinks
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// ModifiedAfter and ModifiedBefore restrict the last modification time of the ShortLinks
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	// Sort is one of "name", "count" or "lastModified". Prefix with "-" to sort descending
+	Sort string
+
+	// Query is a full-text search over the name, target and description of the ShortLinks
+	Query string
+}
+
+func (o *ListOptions) query() url.Values {
+	query := url.Values{}
+	if o == nil {
+		return query
+	}
+
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+
+	params := map[string]string{
+		"continue": o.Continue,
+		"owner":    o.Owner,
+		"domain":   o.Domain,
+		"label":    o.LabelSelector,
+		"sort":     o.Sort,
+		"q":        o.Query,
+	}
+
+	times := map[string]time.Time{
+		"createdAfter":   o.CreatedAfter,
+		"createdBefore":  o.CreatedBefore,
+		"modifiedAfter":  o.ModifiedAfter,
+		"modifiedBefore": o.ModifiedBefore,
+	}
+
+	for param, t := range times {
+		if !t.IsZero() {
+			params[param] = t.Format(time.RFC3339)
+		}
+	}
+
+	for param, value := range params {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+
+	for _, tag := range o.Tags {
+		query.Add("tag", tag)
+	}
+
+	return query
+}
+
+// ShortLinkList is a single page of ShortLinks
+type ShortLinkList struct {
+	Items []ShortLink
+
+	// Continue is the token to fetch the next page. Empty if this is the last page
+	Continue string
+}
+
+func shortlinkPath(name string) string {
+	return "/api/v1/shortlink/" + url.PathEscape(name)
+}
+
+// ListShortLinks returns a single page of the ShortLinks owned by the authenticated user
+func (c *Client) ListShortLinks(ctx context.Context, opts *ListOptions) (*ShortLinkList, error) {
+	items := make([]ShortLink, 0)
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/shortlink/", query: opts.query()}, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShortLinkList{
+		Items:    items,
+		Continue: resp.Header.Get(ContinueHeader),
+	}, nil
+}
+
+// ListAllShortLinks returns all ShortLinks owned by the authenticated user,
+// following the continue token until the last page was fetched
+func (c *Client) ListAllShortLinks(ctx context.Context, opts *ListOptions) ([]ShortLink, error) {
+	pageOpts := ListOptions{}
+	if opts != nil {
+		pageOpts = *opts
+	}
+
+	shortlinks := make([]ShortLink, 0)
+	for {
+		page, err := c.ListShortLinks(ctx, &pageOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		shortlinks = append(shortlinks, page.Items...)
+
+		if page.Continue == "" {
+			return shortlinks, nil
+		}
+
+		// Guard against looping forever on a server which doesn't advance the continue token
+		if page.Continue == pageOpts.Continue {
+			return nil, fmt.Errorf("server returned continue token %q again", page.Continue)
+		}
+
+		pageOpts.Continue = page.Continue
+	}
+}
+
+// GetShortLink returns the ShortLink with the given name
+func (c *Client) GetShortLink(ctx context.Context, name string) (*ShortLink, error) {
+	shortlink := &ShortLink{}
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: shortlinkPath(name)}, shortlink)
+	if err != nil {
+		return nil, err
+	}
+
+	shortlink.ETag = resp.Header.Get("ETag")
+	return shortlink, nil
+}
+
+// CreateShortLink creates a new ShortLink. The owner is always set to the authenticated user
+func (c *Client) CreateShortLink(ctx context.Context, name string, spec v1alpha1.ShortLinkSpec, opts *CreateOptions) (*ShortLink, error) {
+	body := shortlinkRequest{ShortLinkSpec: spec}
+	if opts != nil {
+		body.Labels = opts.Labels
+	}
+
+	shortlink := &ShortLink{}
+	resp, err := c.do(ctx, request{method: http.MethodPost, path: shortlinkPath(name), body: body}, shortlink)
+	if err != nil {
+		return nil, err
+	}
+
+	shortlink.ETag = resp.Header.Get("ETag")
+	return shortlink, nil
+}
+
+// UpdateShortLink replaces the spec of an existing ShortLink
+func (c *Client) UpdateShortLink(ctx context.Context, name string, spec v1alpha1.ShortLinkSpec, opts *UpdateOptions) (*ShortLink, error) {
+	body := shortlinkRequest{ShortLinkSpec: spec}
+	if opts != nil {
+		body.Labels = opts.Labels
+	}
+
+	shortlink := &ShortLink{}
+	resp, err := c.do(ctx, request{method: http.MethodPut, path: shortlinkPath(name), header: opts.header(), body: body}, shortlink)
+	if err != nil {
+		return nil, err
+	}
+
+	shortlink.ETag = resp.Header.Get("ETag")
+	return shortlink, nil
+}
+
+// PatchShortLink partially updates the spec of an existing ShortLink
+func (c *Client) PatchShortLink(ctx context.Context, name string, patchType PatchType, patch []byte, opts *UpdateOptions) (*ShortLink, error) {
+	header := opts.header()
+	header.Set("Content-Type", string(patchType))
+
+	shortlink := &ShortLink{}
+	resp, err := c.do(ctx, request{method: http.MethodPatch, path: shortlinkPath(name), header: header, body: patch}, shortlink)
+	if err != nil {
+		return nil, err
+	}
+
+	shortlink.ETag = resp.Header.Get("ETag")
+	return shortlink, nil
+}
+
+// GetShortLinkHistory returns the audit trail of the ShortLink with the given name, oldest first
+func (c *Client) GetShortLinkHistory(ctx context.Context, name string) ([]model.AuditEntry, error) {
+	history := make([]model.AuditEntry, 0)
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: shortlinkPath(name) + "/history"}, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// ListShortLinkRevisions returns the spec revisions of the ShortLink with the given name, oldest first
+func (c *Client) ListShortLinkRevisions(ctx context.Context, name string) ([]model.Revision, error) {
+	revisions := make([]model.Revision, 0)
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: shortlinkPath(name) + "/revisions"}, &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// RollbackShortLink restores the spec of the given revision of the ShortLink. opts.Labels is ignored
+func (c *Client) RollbackShortLink(ctx context.Context, name string, revision int64, opts *UpdateOptions) (*ShortLink, error) {
+	query := url.Values{}
+	query.Set("revision", strconv.FormatInt(revision, 10))
+
+	shortlink := &ShortLink{}
+	resp, err := c.do(ctx, request{method: http.MethodPost, path: shortlinkPath(name) + "/rollback", query: query, header: opts.header()}, shortlink)
+	if err != nil {
+		return nil, err
+	}
+
+	shortlink.ETag = resp.Header.Get("ETag")
+	return shortlink, nil
+}
+
+// DeleteShortLink deletes the ShortLink with the given name
+func (c *Client) DeleteShortLink(ctx context.Context, name string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: shortlinkPath(name)}, nil)
+	return err
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/model"
+	"github.com/pkg/errors"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// auditConfigMapPrefix prefixes the name of the ConfigMap holding the audit trail of a ShortLink
+	auditConfigMapPrefix = "shortlink-history-"
+
+	// auditConfigMapKey is the key in the ConfigMap data holding the JSON encoded audit entries
+	auditConfigMapKey = "history"
+
+	// MaxAuditEntries is the number of audit entries kept per ShortLink. ConfigMaps are limited to 1MiB,
+	// so once the limit is reached the oldest entries are dropped. Events and logs are not affected by this limit
+	MaxAuditEntries = 500
+)
+
+// AuditClient records changes made to ShortLinks as Kubernetes Events, structured log records and
+// in a ConfigMap per ShortLink, from which the history of the ShortLink can be read back.
+// The ConfigMap isn't owned by the ShortLink, so that the history is kept after the ShortLink is deleted.
+// A ShortLink created again with the same name continues its history
+type AuditClient struct {
+	client   client.Client
+	reader   client.Reader
+	recorder record.EventRecorder
+	tracer   trace.Tracer
+}
+
+// NewAuditClient creates a new AuditClient.
+// reader is used to read the audit ConfigMaps, so that we don't have to cache all ConfigMaps of the namespace
+func NewAuditClient(client client.Client, reader client.Reader, recorder record.EventRecorder, tracer trace.Tracer) *AuditClient {
+	return &AuditClient{
+		client:   client,
+		reader:   reader,
+		recorder: recorder,
+		tracer:   tracer,
+	}
+}
+
+// Record records entry for shortlink
+func (c *AuditClient) Record(ct context.Context, shortlink *v1alpha1.ShortLink, entry model.AuditEntry) error {
+	ctx, span := c.tracer.Start(ct, "AuditClient.Record", trace.WithAttributes(
+		attribute.String("shortlink", shortlink.Name),
+		attribute.String("namespace", shortlink.Namespace),
+		attribute.String("operation", entry.Operation),
+	))
+	defer span.End()
+
+	changes, _ := json.Marshal(entry.Changes)
+
+	otelzap.L().Ctx(ctx).Info("audit",
+		zap.String("shortlink", shortlink.Name),
+		zap.String("namespace", shortlink.Namespace),
+		zap.String("operation", entry.Operation),
+		zap.String("actor", entry.Actor),
+		zap.String("source_ip", entry.SourceIP),
+		zap.Time("timestamp", entry.Timestamp),
+		zap.String("changes", string(changes)),
+	)
+
+	c.recorder.Eventf(shortlink, corev1.EventTypeNormal, auditEventReason(entry.Operation),
+		"%s by %s from %s: %s", entry.Operation, entry.Actor, entry.SourceIP, describeChanges(entry.Changes),
+	)
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return c.appendEntry(ctx, shortlink, entry)
+	})
+
+	if err != nil {
+		span.RecordError(err)
+		return errors.Wrap(err, "Unable to append audit entry")
+	}
+
+	return nil
+}
+
+// History returns the audit entries of shortlink, oldest first
+func (c *AuditClient) History(ct context.Context, shortlink *v1alpha1.ShortLink) ([]model.AuditEntry, error) {
+	ctx, span := c.tracer.Start(ct, "AuditClient.History", trace.WithAttributes(
+		attribute.String("shortlink", shortlink.Name),
+		attribute.String("namespace", shortlink.Namespace),
+	))
+	defer span.End()
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.reader.Get(ctx, auditConfigMapName(shortlink), configMap); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return make([]model.AuditEntry, 0), nil
+		}
+
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return decodeAuditEntries(configMap)
+}
+
+func (c *AuditClient) appendEntry(ctx context.Context, shortlink *v1alpha1.ShortLink, entry model.AuditEntry) error {
+	configMap := &corev1.ConfigMap{}
+
+	err := c.reader.Get(ctx, auditConfigMapName(shortlink), configMap)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	if k8serrors.IsNotFound(err) {
+		configMap = newAuditConfigMap(shortlink)
+		if err := encodeAuditEntries(configMap, []model.AuditEntry{entry}); err != nil {
+			return err
+		}
+
+		return c.client.Create(ctx, configMap)
+	}
+
+	entries, err := decodeAuditEntries(configMap)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, entry)
+	if len(entries) > MaxAuditEntries {
+		entries = entries[len(entries)-MaxAuditEntries:]
+	}
+
+	if err := encodeAuditEntries(configMap, entries); err != nil {
+		return err
+	}
+
+	return c.client.Update(ctx, configMap)
+}
+
+func auditConfigMapName(shortlink *v1alpha1.ShortLink) types.NamespacedName {
+	return types.NamespacedName{
+		Name:      auditConfigMapPrefix + shortlink.Name,
+		Namespace: shortlink.Namespace,
+	}
+}
+
+func newAuditConfigMap(shortlink *v1alpha1.ShortLink) *corev1.ConfigMap {
+	name := auditConfigMapName(shortlink)
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by":    "urlshortener",
+				"urlshortener.cedi.dev/shortlink": shortlink.Name,
+			},
+		},
+	}
+}
+
+func decodeAuditEntries(configMap *corev1.ConfigMap) ([]model.AuditEntry, error) {
+	entries := make([]model.AuditEntry, 0)
+
+	data, ok := configMap.Data[auditConfigMapKey]
+	if !ok || data == "" {
+		return entries, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, errors.Wrapf(err, "Invalid audit history in ConfigMap %s", configMap.Name)
+	}
+
+	return entries, nil
+}
+
+func encodeAuditEntries(configMap *corev1.ConfigMap, entries []model.AuditEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+
+	configMap.Data[auditConfigMapKey] = string(data)
+	return nil
+}
+
+// auditEventReason returns the reason of the Event for an operation, e.g. "Updated" for "update"
+func auditEventReason(operation string) string {
+	switch operation {
+	case model.AuditOperationCreate:
+		return "Created"
+	case model.AuditOperationUpdate:
+		return "Updated"
+	case model.AuditOperationDelete:
+		return "Deleted"
+	case model.AuditOperationRollback:
+		return "RolledBack"
+	}
+
+	return "Changed"
+}
+
+// describeChanges returns a short human readable summary of changes for the Event message
+func describeChanges(changes []model.SpecChange) string {
+	if len(changes) == 0 {
+		return "no changes"
+	}
+
+	descriptions := make([]string, 0, len(changes))
+	for _, change := range changes {
+		before, _ := json.Marshal(change.Before)
+		after, _ := json.Marshal(change.After)
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s -> %s", change.Field, before, after))
+	}
+
+	return strings.Join(descriptions, ", ")
+}
@@ -2,33 +2,54 @@ package client
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/cedi/urlshortener/api/v1alpha1"
 	"github.com/cedi/urlshortener/pkg/model"
 
 	"github.com/pkg/errors"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 type ShortlinkClientAuth struct {
 	tracer trace.Tracer
 	client *ShortlinkClient
+
+	// revisionHistoryLimit is the number of spec revisions kept per ShortLink
+	revisionHistoryLimit int
+
+	// maxShortlinksPerUser is the number of ShortLinks a user may own. 0 means unlimited.
+	// The quota is a soft limit: it is checked before the ShortLink is created, so concurrent requests of the same
+	// user can exceed it by the number of requests in flight, which the per user rate limit keeps small
+	maxShortlinksPerUser int
 }
 
-func NewAuthenticatedShortlinkClient(tracer trace.Tracer, client *ShortlinkClient) *ShortlinkClientAuth {
+func NewAuthenticatedShortlinkClient(tracer trace.Tracer, client *ShortlinkClient, revisionHistoryLimit int, maxShortlinksPerUser int) *ShortlinkClientAuth {
 	return &ShortlinkClientAuth{
-		tracer: tracer,
-		client: client,
+		tracer:               tracer,
+		client:               client,
+		revisionHistoryLimit: revisionHistoryLimit,
+		maxShortlinksPerUser: maxShortlinksPerUser,
 	}
 }
 
-func (c *ShortlinkClientAuth) List(ct context.Context, username string) (*v1alpha1.ShortLinkList, error) {
+// List returns the ShortLinks owned by username, matching the filters in opts.
+// If opts.Limit is set, the list is paginated and ListMeta.Continue holds the token for the next page.
+// All pages are cut from the list in the cache, so the ShortLinks are sorted across pages
+func (c *ShortlinkClientAuth) List(ct context.Context, username string, opts *ShortLinkListOptions) (*v1alpha1.ShortLinkList, error) {
 	ctx, span := c.tracer.Start(ct, "ShortlinkClientAuth.List")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("username", username))
 
+	if opts == nil {
+		opts = &ShortLinkListOptions{}
+	}
+
 	list, err := c.client.List(ctx)
 	if err != nil {
 		return nil, err
@@ -36,16 +57,44 @@ func (c *ShortlinkClientAuth) List(ct context.Context, username string) (*v1alph
 
 	userShortlinkList := v1alpha1.ShortLinkList{
 		TypeMeta: list.TypeMeta,
-		ListMeta: list.ListMeta,
 		Items:    make([]v1alpha1.ShortLink, 0),
 	}
 
 	for _, shortLink := range list.Items {
-		if shortLink.IsOwnedBy(username) {
+		if shortLink.IsOwnedBy(username) && opts.Matches(&shortLink) {
 			userShortlinkList.Items = append(userShortlinkList.Items, shortLink)
 		}
 	}
 
+	opts.Sort(userShortlinkList.Items)
+
+	if opts.Limit == 0 && opts.Continue == "" {
+		return &userShortlinkList, nil
+	}
+
+	start := 0
+	if opts.Continue != "" {
+		token, err := decodeContinueToken(opts.Continue)
+		if err != nil {
+			span.RecordError(err)
+			return nil, k8serrors.NewBadRequest(err.Error())
+		}
+
+		start = token.start(opts, userShortlinkList.Items)
+	}
+
+	total := len(userShortlinkList.Items)
+	end := total
+	if opts.Limit > 0 && int64(end-start) > opts.Limit {
+		end = start + int(opts.Limit)
+	}
+
+	userShortlinkList.Items = userShortlinkList.Items[start:end]
+
+	if end > start && end < total {
+		userShortlinkList.Continue = newContinueToken(&userShortlinkList.Items[end-start-1]).encode()
+	}
+
 	return &userShortlinkList, nil
 }
 
@@ -55,13 +104,13 @@ func (c *ShortlinkClientAuth) Get(ct context.Context, username string, name stri
 
 	span.SetAttributes(attribute.String("username", username))
 
-	shortLink, err := c.client.Get(ctx, name)
+	shortLink, err := c.client.GetBySlug(ctx, name)
 	if err != nil {
 		return nil, errors.Wrap(err, "Unable to get shortlink")
 	}
 
 	if !shortLink.IsOwnedBy(username) {
-		return nil, model.NewNotAllowedError(username, "delete", shortLink.Name)
+		return nil, model.NewNotAllowedError(username, "get", shortLink.Name)
 	}
 
 	return shortLink, nil
@@ -74,6 +123,29 @@ func (c *ShortlinkClientAuth) Create(ct context.Context, username string, shortL
 	span.SetAttributes(attribute.String("username", username))
 
 	shortLink.Spec.Owner = username
+
+	if c.maxShortlinksPerUser > 0 {
+		shortlinks, err := c.client.List(ctx)
+		if err != nil {
+			return errors.Wrap(err, "Unable to count shortlinks of user")
+		}
+
+		owned := 0
+		for _, shortlink := range shortlinks.Items {
+			if shortlink.Spec.Owner == username {
+				owned++
+			}
+		}
+
+		if owned >= c.maxShortlinksPerUser {
+			return model.NewQuotaExceededError(username, c.maxShortlinksPerUser)
+		}
+	}
+
+	if err := addRevision(shortLink, nil, username, c.revisionHistoryLimit); err != nil {
+		return errors.Wrap(err, "Unable to record revision")
+	}
+
 	return c.client.Create(ctx, shortLink)
 }
 
@@ -84,19 +156,83 @@ func (c *ShortlinkClientAuth) Update(ct context.Context, username string, shortL
 	span.SetAttributes(attribute.String("username", username))
 
 	if !shortLink.IsOwnedBy(username) {
-		return model.NewNotAllowedError(username, "delete", shortLink.Name)
+		return model.NewNotAllowedError(username, "update", shortLink.Name)
+	}
+
+	revisions, err := GetRevisions(shortLink)
+	if err != nil {
+		return err
+	}
+
+	// ShortLinks created outside of the API (e.g. using kubectl) have no revisions yet.
+	// Record their stored spec as the first revision, so it can be restored later on
+	if len(revisions) == 0 {
+		stored, err := c.client.GetNameNamespace(ctx, shortLink.Name, shortLink.Namespace)
+		if err != nil {
+			return errors.Wrap(err, "Unable to get shortlink")
+		}
+
+		revisions = append(revisions, model.Revision{
+			Revision:  1,
+			ChangedBy: stored.Status.ChangedBy,
+			Timestamp: stored.CreationTimestamp.UTC(),
+			Spec:      stored.Spec,
+		})
+	}
+
+	if err := addRevision(shortLink, revisions, username, c.revisionHistoryLimit); err != nil {
+		return errors.Wrap(err, "Unable to record revision")
 	}
 
 	if err := c.client.Update(ctx, shortLink); err != nil {
 		return err
 	}
 
-	shortLink.Status.ChangedBy = username
-	return c.client.UpdateStatus(ctx, shortLink)
+	// The change is persisted at this point and must be reported as such, so that it is audited.
+	// Failing to record who made it is therefore only logged
+	if err := c.client.UpdateChangedBy(ctx, shortLink, username); err != nil {
+		otelzap.L().Ctx(ctx).Warn("Unable to record the user who changed the ShortLink",
+			zap.String("shortlink", shortLink.Name),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+	}
+
+	return nil
+}
+
+// Rollback restores the spec of the given revision of shortLink, keeping the current owners.
+// The restored spec is recorded as a new revision
+func (c *ShortlinkClientAuth) Rollback(ct context.Context, username string, shortLink *v1alpha1.ShortLink, revision int64) error {
+	ctx, span := c.tracer.Start(ct, "ShortlinkClientAuth.Rollback")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("username", username), attribute.Int64("revision", revision))
+
+	if !shortLink.IsOwnedBy(username) {
+		return model.NewNotAllowedError(username, "rollback", shortLink.Name)
+	}
+
+	rev, err := GetRevision(shortLink, revision)
+	if err != nil {
+		return err
+	}
+
+	if rev == nil {
+		return k8serrors.NewNotFound(v1alpha1.GroupVersion.WithResource("shortlinks/revisions").GroupResource(), fmt.Sprintf("%s/%d", shortLink.Name, revision))
+	}
+
+	// Rolling back must not change who is allowed to administrate the ShortLink
+	spec := rev.Spec.DeepCopy()
+	spec.Owner = shortLink.Spec.Owner
+	spec.CoOwners = shortLink.Spec.CoOwners
+
+	shortLink.Spec = *spec
+	return c.Update(ctx, username, shortLink)
 }
 
 func (c *ShortlinkClientAuth) Delete(ct context.Context, username string, shortLink *v1alpha1.ShortLink) error {
-	ctx, span := c.tracer.Start(ct, "ShortlinkClientAuth.Update")
+	ctx, span := c.tracer.Start(ct, "ShortlinkClientAuth.Delete")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("username", username))
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// queryDefaultsTTL is how long the defaults of a namespace are cached, as they are needed for every redirect
+const queryDefaultsTTL = time.Minute
+
+// QueryDefaultsClient reads the default query parameters of the ShortLinks of a namespace from a ConfigMap,
+// whose data maps parameter names to values
+type QueryDefaultsClient struct {
+	reader        client.Reader
+	tracer        trace.Tracer
+	configMapName string
+
+	mu    sync.Mutex
+	cache map[string]cachedQueryDefaults
+}
+
+type cachedQueryDefaults struct {
+	parameters map[string]string
+	expires    time.Time
+}
+
+// NewQueryDefaultsClient creates a new QueryDefaultsClient reading the ConfigMap configMapName.
+// reader is used to read the ConfigMap, so that we don't have to cache all ConfigMaps of the namespace.
+// An empty configMapName disables the defaults
+func NewQueryDefaultsClient(reader client.Reader, tracer trace.Tracer, configMapName string) *QueryDefaultsClient {
+	return &QueryDefaultsClient{
+		reader:        reader,
+		tracer:        tracer,
+		configMapName: configMapName,
+		cache:         make(map[string]cachedQueryDefaults),
+	}
+}
+
+// Get returns the default query parameters of namespace. A namespace without the ConfigMap has no defaults
+func (c *QueryDefaultsClient) Get(ct context.Context, namespace string) (map[string]string, error) {
+	if c.configMapName == "" {
+		return nil, nil
+	}
+
+	ctx, span := c.tracer.Start(ct, "QueryDefaultsClient.Get", trace.WithAttributes(attribute.String("namespace", namespace), attribute.String("configmap", c.configMapName)))
+	defer span.End()
+
+	c.mu.Lock()
+	cached, ok := c.cache[namespace]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(cached.expires) {
+		return cached.parameters, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: c.configMapName}, configMap); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			span.RecordError(err)
+			return nil, err
+		}
+
+		configMap.Data = nil
+	}
+
+	c.mu.Lock()
+	c.cache[namespace] = cachedQueryDefaults{
+		parameters: configMap.Data,
+		expires:    time.Now().Add(queryDefaultsTTL),
+	}
+	c.mu.Unlock()
+
+	return configMap.Data, nil
+}
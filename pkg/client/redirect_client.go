@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"os"
+	"strings"
 
 	"github.com/cedi/urlshortener/api/v1alpha1"
 	"github.com/pkg/errors"
@@ -175,3 +176,62 @@ func (c *RedirectClient) SaveStatus(ct context.Context, Redirect *v1alpha1.Redir
 
 	return err
 }
+
+// RedirectSourceField is the field index of Redirects by all their source hosts
+const RedirectSourceField = "spec.source"
+
+// IndexRedirectSource registers the RedirectSourceField index, which is required by GetNativeBySource
+func IndexRedirectSource(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &v1alpha1.Redirect{}, RedirectSourceField, func(obj client.Object) []string {
+		redirect, ok := obj.(*v1alpha1.Redirect)
+		if !ok {
+			return nil
+		}
+
+		hosts := make([]string, 0)
+		for _, host := range redirect.Hosts() {
+			if host != "" {
+				hosts = append(hosts, strings.ToLower(host))
+			}
+		}
+
+		return hosts
+	})
+}
+
+// GetNativeBySource returns the native Redirect in namespace for the given source host, or nil if there is none
+func (c *RedirectClient) GetNativeBySource(ct context.Context, namespace string, host string) (*v1alpha1.Redirect, error) {
+	ctx, span := c.tracer.Start(ct, "RedirectClient.GetNativeBySource", trace.WithAttributes(attribute.String("host", host), attribute.String("namespace", namespace)))
+	defer span.End()
+
+	redirects := &v1alpha1.RedirectList{}
+
+	err := c.client.List(ctx, redirects, client.InNamespace(namespace), client.MatchingFields{RedirectSourceField: strings.ToLower(host)})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	for _, redirect := range redirects.Items {
+		if redirect.Spec.Native {
+			return &redirect, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// IncrementInvocationCount increases the invocation count in the status of redirect by one
+func (c *RedirectClient) IncrementInvocationCount(ct context.Context, redirect *v1alpha1.Redirect) error {
+	ctx, span := c.tracer.Start(ct, "RedirectClient.IncrementInvocationCount", trace.WithAttributes(attribute.String("redirect", redirect.ObjectMeta.Name), attribute.String("namespace", redirect.ObjectMeta.Namespace)))
+	defer span.End()
+
+	redirect.Status.Count = redirect.Status.Count + 1
+
+	if err := c.client.Status().Update(ctx, redirect); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/model"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+const (
+	// RevisionsAnnotation is the annotation holding the JSON encoded revisions of a ShortLink
+	RevisionsAnnotation = "urlshortener.cedi.dev/revisions"
+
+	// DefaultRevisionHistoryLimit is the number of revisions kept per ShortLink if nothing else is configured
+	DefaultRevisionHistoryLimit = 10
+)
+
+// GetRevisions returns the revisions of shortlink, oldest first
+func GetRevisions(shortlink *v1alpha1.ShortLink) ([]model.Revision, error) {
+	revisions := make([]model.Revision, 0)
+
+	data, ok := shortlink.Annotations[RevisionsAnnotation]
+	if !ok || data == "" {
+		return revisions, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &revisions); err != nil {
+		return nil, errors.Wrapf(err, "Invalid %s annotation on ShortLink %s", RevisionsAnnotation, shortlink.Name)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns the revision with the given number
+func GetRevision(shortlink *v1alpha1.ShortLink, revision int64) (*model.Revision, error) {
+	revisions, err := GetRevisions(shortlink)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range revisions {
+		if revisions[idx].Revision == revision {
+			return &revisions[idx], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// addRevision adds the current spec of shortlink as a new revision to revisions and stores them
+// in the annotation of shortlink, keeping at most limit revisions.
+// If the spec didn't change since the latest revision, no revision is added
+func addRevision(shortlink *v1alpha1.ShortLink, revisions []model.Revision, changedBy string, limit int) error {
+	var next int64 = 1
+
+	if len(revisions) > 0 {
+		latest := revisions[len(revisions)-1]
+		if equality.Semantic.DeepEqual(latest.Spec, shortlink.Spec) {
+			return nil
+		}
+
+		next = latest.Revision + 1
+	}
+
+	revisions = append(revisions, model.Revision{
+		Revision:  next,
+		ChangedBy: changedBy,
+		Timestamp: time.Now().UTC(),
+		Spec:      *shortlink.Spec.DeepCopy(),
+	})
+
+	if limit > 0 && len(revisions) > limit {
+		revisions = revisions[len(revisions)-limit:]
+	}
+
+	data, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+
+	if shortlink.Annotations == nil {
+		shortlink.Annotations = make(map[string]string)
+	}
+
+	shortlink.Annotations[RevisionsAnnotation] = string(data)
+	return nil
+}
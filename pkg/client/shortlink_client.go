@@ -3,25 +3,32 @@ package client
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/slug"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // ShortlinkClient is a Kubernetes client for easy CRUD operations
 type ShortlinkClient struct {
 	client client.Client
+	reader client.Reader
 	tracer trace.Tracer
 }
 
-// NewShortlinkClient creates a new shortlink Client
-func NewShortlinkClient(client client.Client, tracer trace.Tracer) *ShortlinkClient {
+// NewShortlinkClient creates a new shortlink Client.
+// reader is used for reads which can't be served from the cache, e.g. of the slug claims
+func NewShortlinkClient(client client.Client, reader client.Reader, tracer trace.Tracer) *ShortlinkClient {
 	return &ShortlinkClient{
 		client: client,
+		reader: reader,
 		tracer: tracer,
 	}
 }
@@ -70,6 +77,110 @@ func (c *ShortlinkClient) GetNamespaced(ct context.Context, nameNamespaced types
 	return shortlink, nil
 }
 
+// ShortLinkSlugField is the field index of ShortLinks by their normalized slug and aliases
+const ShortLinkSlugField = "spec.slug"
+
+// IndexShortLinkSlug registers the ShortLinkSlugField index, which is required by GetBySlug
+func IndexShortLinkSlug(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &v1alpha1.ShortLink{}, ShortLinkSlugField, func(obj client.Object) []string {
+		shortlink, ok := obj.(*v1alpha1.ShortLink)
+		if !ok {
+			return nil
+		}
+
+		slugs := make([]string, 0)
+		for _, name := range shortlink.GetSlugs() {
+			if name != "" {
+				slugs = append(slugs, slug.Normalize(name))
+			}
+		}
+
+		return slugs
+	})
+}
+
+// GetBySlug returns the ShortLink served at slug or having slug as alias in the current namespace, ignoring the
+// case of slug. If several ShortLinks use the same slug, the one which claimed it wins, otherwise the oldest one
+func (c *ShortlinkClient) GetBySlug(ct context.Context, name string) (*v1alpha1.ShortLink, error) {
+	ctx, span := c.tracer.Start(ct, "ShortlinkClient.GetBySlug", trace.WithAttributes(attribute.String("slug", name)))
+	defer span.End()
+
+	// try to read the namespace from /var/run
+	namespace, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		span.RecordError(err)
+		return nil, errors.Wrap(err, "Unable to read current namespace")
+	}
+
+	shortlinks := &v1alpha1.ShortLinkList{}
+
+	err = c.client.List(ctx, shortlinks,
+		client.InNamespace(string(namespace)),
+		client.MatchingFields{ShortLinkSlugField: slug.Normalize(name)},
+	)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	switch len(shortlinks.Items) {
+	case 0:
+		return nil, k8serrors.NewNotFound(v1alpha1.GroupVersion.WithResource("shortlinks").GroupResource(), name)
+	case 1:
+		return &shortlinks.Items[0], nil
+	}
+
+	// The duplicates are reported by the Valid condition of the ShortLinks which didn't claim the slug
+	holder, err := c.SlugHolder(ctx, string(namespace), name)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	var oldest *v1alpha1.ShortLink
+	for i := range shortlinks.Items {
+		shortlink := &shortlinks.Items[i]
+
+		if shortlink.Name == holder {
+			return shortlink, nil
+		}
+
+		if oldest == nil || olderThan(shortlink, oldest) {
+			oldest = shortlink
+		}
+	}
+
+	return oldest, nil
+}
+
+// olderThan reports whether a was created before b, using the name to order ShortLinks created at the same time
+func olderThan(a, b *v1alpha1.ShortLink) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return a.Name < b.Name
+}
+
+// ListSlugs returns the slugs and aliases of all ShortLinks in the current namespace
+func (c *ShortlinkClient) ListSlugs(ct context.Context) ([]string, error) {
+	ctx, span := c.tracer.Start(ct, "ShortlinkClient.ListSlugs")
+	defer span.End()
+
+	shortlinks, err := c.List(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	slugs := make([]string, 0, len(shortlinks.Items))
+	for _, shortlink := range shortlinks.Items {
+		slugs = append(slugs, shortlink.GetSlugs()...)
+	}
+
+	return slugs, nil
+}
+
 // List returns a list of all Shortlinks in the current namespace
 func (c *ShortlinkClient) List(ct context.Context) (*v1alpha1.ShortLinkList, error) {
 	ctx, span := c.tracer.Start(ct, "ShortlinkClient.List")
@@ -124,12 +235,76 @@ func (c *ShortlinkClient) UpdateStatus(ct context.Context, shortlink *v1alpha1.S
 	return err
 }
 
-func (c *ShortlinkClient) IncrementInvocationCount(ct context.Context, shortlink *v1alpha1.ShortLink) error {
-	ctx, span := c.tracer.Start(ct, "ShortlinkClient.IncrementInvocationCount", trace.WithAttributes(attribute.String("shortlink", shortlink.ObjectMeta.Name), attribute.String("namespace", shortlink.ObjectMeta.Namespace)))
+// UpdateChangedBy records username as the last one who changed shortlink. The status is also written by the
+// reconciler and by the invocation counts, so on conflicts it is read again from the API server and written again
+func (c *ShortlinkClient) UpdateChangedBy(ct context.Context, shortlink *v1alpha1.ShortLink, username string) error {
+	ctx, span := c.tracer.Start(ct, "ShortlinkClient.UpdateChangedBy", trace.WithAttributes(attribute.String("shortlink", shortlink.ObjectMeta.Name), attribute.String("namespace", shortlink.ObjectMeta.Namespace)))
+	defer span.End()
+
+	lastModified := time.Now().UTC().Format(time.RFC3339)
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		shortlink.Status.ChangedBy = username
+		shortlink.Status.LastModified = lastModified
+
+		err := c.client.Status().Update(ctx, shortlink)
+		if !k8serrors.IsConflict(err) {
+			return err
+		}
+
+		latest := &v1alpha1.ShortLink{}
+		if err := c.reader.Get(ctx, client.ObjectKeyFromObject(shortlink), latest); err != nil {
+			return err
+		}
+
+		shortlink.ResourceVersion = latest.ResourceVersion
+		shortlink.Status = latest.Status
+
+		return err
+	})
+
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
+}
+
+// IncrementInvocationCount increases the invocation count in the status of shortlink by one.
+// If shortlink was invoked by one of its aliases, the count of alias is increased as well
+func (c *ShortlinkClient) IncrementInvocationCount(ct context.Context, shortlink *v1alpha1.ShortLink, alias string) error {
+	ctx, span := c.tracer.Start(ct, "ShortlinkClient.IncrementInvocationCount", trace.WithAttributes(attribute.String("shortlink", shortlink.ObjectMeta.Name), attribute.String("namespace", shortlink.ObjectMeta.Namespace), attribute.String("alias", alias)))
 	defer span.End()
 
 	shortlink.Status.Count = shortlink.Status.Count + 1
 
+	if alias != "" {
+		if shortlink.Status.AliasCounts == nil {
+			shortlink.Status.AliasCounts = make(map[string]int)
+		}
+
+		shortlink.Status.AliasCounts[alias] = shortlink.Status.AliasCounts[alias] + 1
+	}
+
+	if err := c.client.Status().Update(ctx, shortlink); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// IncrementCrawlerCount counts a request of shortlink by crawler separately from the invocations
+func (c *ShortlinkClient) IncrementCrawlerCount(ct context.Context, shortlink *v1alpha1.ShortLink, crawler string) error {
+	ctx, span := c.tracer.Start(ct, "ShortlinkClient.IncrementCrawlerCount", trace.WithAttributes(attribute.String("shortlink", shortlink.ObjectMeta.Name), attribute.String("namespace", shortlink.ObjectMeta.Namespace), attribute.String("crawler", crawler)))
+	defer span.End()
+
+	if shortlink.Status.CrawlerCounts == nil {
+		shortlink.Status.CrawlerCounts = make(map[string]int)
+	}
+
+	shortlink.Status.CrawlerCounts[crawler] = shortlink.Status.CrawlerCounts[crawler] + 1
+
 	if err := c.client.Status().Update(ctx, shortlink); err != nil {
 		span.RecordError(err)
 		return err
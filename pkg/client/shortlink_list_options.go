@@ -0,0 +1,213 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	SortByName         = "name"
+	SortByCount        = "count"
+	SortByLastModified = "lastModified"
+)
+
+// ShortLinkListOptions filters, sorts and paginates the ShortLinks returned by ShortlinkClientAuth.List
+type ShortLinkListOptions struct {
+	// Limit is the maximum number of ShortLinks returned. 0 returns all ShortLinks
+	Limit int64
+
+	// Continue is the continue token returned with the previous page
+	Continue string
+
+	// LabelSelector only returns ShortLinks with matching labels
+	LabelSelector labels.Selector
+
+	// Owner only returns ShortLinks whose Spec.Owner equals Owner
+	Owner string
+
+	// Tags only returns ShortLinks tagged with all of the given tags
+	Tags []string
+
+	// TargetDomain only returns ShortLinks whose target is on this domain or one of its subdomains
+	TargetDomain string
+
+	// CreatedAfter and CreatedBefore restrict the creationTimestamp of the ShortLinks
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// ModifiedAfter and ModifiedBefore restrict the Status.LastModified of the ShortLinks
+	ModifiedAfter  *time.Time
+	ModifiedBefore *time.Time
+
+	// Query is a case-insensitive full-text search over the name, target and description of the ShortLinks
+	Query string
+
+	// SortBy is one of SortByName, SortByCount or SortByLastModified. ShortLinks sorting equally are sorted by name
+	SortBy string
+
+	// SortDescending reverses the sort order
+	SortDescending bool
+}
+
+// Matches returns true if the shortlink satisfies all filters of the options
+func (o *ShortLinkListOptions) Matches(shortlink *v1alpha1.ShortLink) bool {
+	if o.Owner != "" && shortlink.Spec.Owner != o.Owner {
+		return false
+	}
+
+	if o.LabelSelector != nil && !o.LabelSelector.Matches(labels.Set(shortlink.Labels)) {
+		return false
+	}
+
+	if !shortlink.HasTags(o.Tags...) {
+		return false
+	}
+
+	if o.TargetDomain != "" && !targetOnDomain(shortlink.Spec.Target, o.TargetDomain) {
+		return false
+	}
+
+	created := shortlink.CreationTimestamp.Time
+	if o.CreatedAfter != nil && created.Before(*o.CreatedAfter) {
+		return false
+	}
+
+	if o.CreatedBefore != nil && created.After(*o.CreatedBefore) {
+		return false
+	}
+
+	if o.ModifiedAfter != nil || o.ModifiedBefore != nil {
+		modified := lastModified(shortlink)
+
+		if o.ModifiedAfter != nil && modified.Before(*o.ModifiedAfter) {
+			return false
+		}
+
+		if o.ModifiedBefore != nil && modified.After(*o.ModifiedBefore) {
+			return false
+		}
+	}
+
+	if o.Query != "" {
+		query := strings.ToLower(o.Query)
+		if !strings.Contains(strings.ToLower(shortlink.Name), query) &&
+			!strings.Contains(strings.ToLower(shortlink.Spec.Target), query) &&
+			!strings.Contains(strings.ToLower(shortlink.Spec.Description), query) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sort sorts the shortlinks according to SortBy and SortDescending
+func (o *ShortLinkListOptions) Sort(shortlinks []v1alpha1.ShortLink) {
+	sort.SliceStable(shortlinks, func(i, j int) bool {
+		return o.less(&shortlinks[i], &shortlinks[j])
+	})
+}
+
+// less reports whether a sorts before b. The name breaks ties, so that the order is the same for every page
+func (o *ShortLinkListOptions) less(a *v1alpha1.ShortLink, b *v1alpha1.ShortLink) bool {
+	if o.SortDescending {
+		a, b = b, a
+	}
+
+	switch o.SortBy {
+	case SortByCount:
+		if a.Status.Count != b.Status.Count {
+			return a.Status.Count < b.Status.Count
+		}
+	case SortByLastModified:
+		if modifiedA, modifiedB := lastModified(a), lastModified(b); !modifiedA.Equal(modifiedB) {
+			return modifiedA.Before(modifiedB)
+		}
+	}
+
+	return a.Name < b.Name
+}
+
+// continueToken marks the end of a page by the sort key and name of its last ShortLink, so that the next page
+// neither skips nor repeats ShortLinks if others were created or deleted in between, including the last ShortLink
+type continueToken struct {
+	After        string    `json:"after"`
+	Count        int       `json:"count,omitempty"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// newContinueToken returns the token of the page ending with shortlink
+func newContinueToken(shortlink *v1alpha1.ShortLink) continueToken {
+	return continueToken{
+		After:        shortlink.Name,
+		Count:        shortlink.Status.Count,
+		LastModified: lastModified(shortlink),
+	}
+}
+
+func (t continueToken) encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeContinueToken(token string) (continueToken, error) {
+	decoded := continueToken{}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &decoded)
+	}
+
+	if err != nil || decoded.After == "" {
+		return decoded, fmt.Errorf("invalid continue token %q", token)
+	}
+
+	return decoded, nil
+}
+
+// start returns the index of the first of the shortlinks sorted by opts which sorts after the end of the
+// previous page
+func (t continueToken) start(opts *ShortLinkListOptions, shortlinks []v1alpha1.ShortLink) int {
+	anchor := &v1alpha1.ShortLink{}
+	anchor.Name = t.After
+	anchor.Status.Count = t.Count
+	anchor.Status.LastModified = t.LastModified.UTC().Format(time.RFC3339Nano)
+
+	return sort.Search(len(shortlinks), func(i int) bool {
+		return opts.less(anchor, &shortlinks[i])
+	})
+}
+
+// lastModified returns Status.LastModified, falling back to the creationTimestamp
+// for ShortLinks which have never been modified
+func lastModified(shortlink *v1alpha1.ShortLink) time.Time {
+	if modified, err := time.Parse(time.RFC3339, shortlink.Status.LastModified); err == nil {
+		return modified
+	}
+
+	return shortlink.CreationTimestamp.Time
+}
+
+// targetOnDomain checks if the host of target equals domain or is a subdomain of it
+func targetOnDomain(target string, domain string) bool {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
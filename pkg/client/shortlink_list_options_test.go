@@ -0,0 +1,83 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func shortlinksNamed(names ...string) []v1alpha1.ShortLink {
+	shortlinks := make([]v1alpha1.ShortLink, 0, len(names))
+	for _, name := range names {
+		shortlinks = append(shortlinks, v1alpha1.ShortLink{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+
+	return shortlinks
+}
+
+func TestContinueToken(t *testing.T) {
+	opts := &ShortLinkListOptions{}
+
+	token, err := decodeContinueToken(newContinueToken(&shortlinksNamed("b")[0]).encode())
+	if err != nil {
+		t.Fatalf("decodeContinueToken() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		shortlinks []v1alpha1.ShortLink
+		want       int
+	}{
+		{name: "unchanged", shortlinks: shortlinksNamed("a", "b", "c"), want: 2},
+		{name: "created before", shortlinks: shortlinksNamed("0", "a", "b", "c"), want: 3},
+		{name: "deleted", shortlinks: shortlinksNamed("a", "c", "d"), want: 1},
+		{name: "shrunk", shortlinks: shortlinksNamed("a"), want: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := token.start(opts, test.shortlinks); got != test.want {
+				t.Errorf("start() = %d, want %d", got, test.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"not base64!", "bm90IGpzb24", continueToken{}.encode()} {
+		if _, err := decodeContinueToken(invalid); err == nil {
+			t.Errorf("decodeContinueToken(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestContinueTokenSortedByCount(t *testing.T) {
+	opts := &ShortLinkListOptions{SortBy: SortByCount, SortDescending: true}
+
+	shortlinks := shortlinksNamed("a", "b", "c", "d")
+	for i := range shortlinks {
+		shortlinks[i].Status.Count = 10 - i
+	}
+
+	// The page ended with b, which was deleted since
+	token, err := decodeContinueToken(newContinueToken(&shortlinks[1]).encode())
+	if err != nil {
+		t.Fatalf("decodeContinueToken() failed: %v", err)
+	}
+
+	remaining := append([]v1alpha1.ShortLink{shortlinks[0]}, shortlinks[2:]...)
+	if got := token.start(opts, remaining); remaining[got].Name != "c" {
+		t.Errorf("start() = %s, want c", remaining[got].Name)
+	}
+}
+
+func TestSortBreaksTiesByName(t *testing.T) {
+	shortlinks := shortlinksNamed("c", "a", "b")
+	shortlinks[0].Status.Count = 1
+
+	opts := &ShortLinkListOptions{SortBy: SortByCount, SortDescending: true}
+	opts.Sort(shortlinks)
+
+	if shortlinks[0].Name != "c" || shortlinks[1].Name != "b" || shortlinks[2].Name != "a" {
+		t.Errorf("sorted to %s, %s, %s, want c, b, a", shortlinks[0].Name, shortlinks[1].Name, shortlinks[2].Name)
+	}
+}
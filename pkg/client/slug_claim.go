@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/slug"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// slugClaimPrefix prefixes the name of the ConfigMap claiming a slug, which is followed by the hash of the
+	// normalized slug, as slugs aren't necessarily valid object names
+	slugClaimPrefix = "shortlink-slug-"
+
+	// slugClaimSlugKey is the key in the ConfigMap data holding the normalized slug
+	slugClaimSlugKey = "slug"
+
+	// slugClaimShortLinkKey is the key in the ConfigMap data holding the name of the ShortLink using the slug
+	slugClaimShortLinkKey = "shortlink"
+
+	// slugClaimTimeKey is the key in the ConfigMap data holding the time the slug was claimed
+	slugClaimTimeKey = "claimedAt"
+
+	// slugClaimGracePeriod is how long a claim is honoured if its ShortLink doesn't exist. The API claims the slugs
+	// before creating the ShortLink, so such a claim is only stale once the creation must have failed
+	slugClaimGracePeriod = time.Minute
+)
+
+// SlugConflictError is returned if a slug is already used by another ShortLink
+type SlugConflictError struct {
+	Slug      string
+	ShortLink string
+}
+
+func (e *SlugConflictError) Error() string {
+	return fmt.Sprintf("slug %q is already used by ShortLink %s", e.Slug, e.ShortLink)
+}
+
+// ClaimSlugs claims the slug and aliases of shortlink, so that no other ShortLink can use them.
+// A slug is claimed by creating a ConfigMap named after the normalized slug, which fails if another ShortLink
+// created it first. Once shortlink exists, it owns its claims and they are garbage collected together with it.
+// Claims of ShortLinks which no longer use the slug are taken over.
+// A *SlugConflictError is returned if a slug is claimed by another ShortLink
+func (c *ShortlinkClient) ClaimSlugs(ct context.Context, shortlink *v1alpha1.ShortLink) error {
+	ctx, span := c.tracer.Start(ct, "ShortlinkClient.ClaimSlugs", trace.WithAttributes(attribute.String("name", shortlink.Name)))
+	defer span.End()
+
+	namespace := shortlink.Namespace
+	if namespace == "" {
+		// try to read the namespace from /var/run
+		namespaceByte, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+		if err != nil {
+			span.RecordError(err)
+			return errors.Wrap(err, "Unable to read current namespace")
+		}
+
+		namespace = string(namespaceByte)
+	}
+
+	for _, name := range shortlink.GetSlugs() {
+		// A concurrent claim of the same slug is resolved by trying again
+		err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+			return k8serrors.IsConflict(err) || k8serrors.IsAlreadyExists(err)
+		}, func() error {
+			return c.claimSlug(ctx, namespace, shortlink, name)
+		})
+
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SlugHolder returns the name of the ShortLink which claimed slug, or an empty string if the slug isn't claimed
+func (c *ShortlinkClient) SlugHolder(ct context.Context, namespace string, name string) (string, error) {
+	ctx, span := c.tracer.Start(ct, "ShortlinkClient.SlugHolder", trace.WithAttributes(attribute.String("slug", name)))
+	defer span.End()
+
+	claim := &corev1.ConfigMap{}
+	if err := c.reader.Get(ctx, slugClaimName(namespace, slug.Normalize(name)), claim); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", nil
+		}
+
+		span.RecordError(err)
+		return "", err
+	}
+
+	return claim.Data[slugClaimShortLinkKey], nil
+}
+
+func (c *ShortlinkClient) claimSlug(ctx context.Context, namespace string, shortlink *v1alpha1.ShortLink, name string) error {
+	normalized := slug.Normalize(name)
+
+	claim := &corev1.ConfigMap{}
+	err := c.reader.Get(ctx, slugClaimName(namespace, normalized), claim)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	if k8serrors.IsNotFound(err) {
+		return c.client.Create(ctx, newSlugClaim(namespace, normalized, shortlink))
+	}
+
+	holder := claim.Data[slugClaimShortLinkKey]
+	if holder == shortlink.Name && (shortlink.UID == "" || metav1.IsControlledBy(claim, shortlink)) {
+		return nil
+	}
+
+	if holder != shortlink.Name {
+		held, err := c.isSlugHeld(ctx, claim, normalized)
+		if err != nil {
+			return err
+		}
+
+		if held {
+			return &SlugConflictError{Slug: name, ShortLink: holder}
+		}
+	}
+
+	// Take over the claim, or adopt it once shortlink exists
+	update := newSlugClaim(namespace, normalized, shortlink)
+	update.ResourceVersion = claim.ResourceVersion
+	if holder == shortlink.Name {
+		update.Data[slugClaimTimeKey] = claim.Data[slugClaimTimeKey]
+	}
+
+	return c.client.Update(ctx, update)
+}
+
+// isSlugHeld reports whether the ShortLink of claim still uses the slug
+func (c *ShortlinkClient) isSlugHeld(ctx context.Context, claim *corev1.ConfigMap, normalized string) (bool, error) {
+	holder := &v1alpha1.ShortLink{}
+
+	err := c.reader.Get(ctx, types.NamespacedName{Name: claim.Data[slugClaimShortLinkKey], Namespace: claim.Namespace}, holder)
+	if k8serrors.IsNotFound(err) {
+		claimedAt, err := time.Parse(time.RFC3339, claim.Data[slugClaimTimeKey])
+		return err == nil && time.Since(claimedAt) < slugClaimGracePeriod, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range holder.GetSlugs() {
+		if slug.Normalize(name) == normalized {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func slugClaimName(namespace string, normalized string) types.NamespacedName {
+	hash := sha256.Sum256([]byte(normalized))
+
+	return types.NamespacedName{
+		Name:      slugClaimPrefix + hex.EncodeToString(hash[:20]),
+		Namespace: namespace,
+	}
+}
+
+func newSlugClaim(namespace string, normalized string, shortlink *v1alpha1.ShortLink) *corev1.ConfigMap {
+	name := slugClaimName(namespace, normalized)
+
+	claim := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "urlshortener",
+			},
+		},
+		Data: map[string]string{
+			slugClaimSlugKey:      normalized,
+			slugClaimShortLinkKey: shortlink.Name,
+			slugClaimTimeKey:      time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+
+	// The ShortLink doesn't exist yet if the API claims the slugs before creating it
+	if shortlink.UID != "" {
+		isController := true
+		claim.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: v1alpha1.GroupVersion.String(),
+				Kind:       "ShortLink",
+				Name:       shortlink.Name,
+				UID:        shortlink.UID,
+				Controller: &isController,
+			},
+		}
+	}
+
+	return claim
+}
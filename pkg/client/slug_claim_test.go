@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestShortlinkClient(objects ...*v1alpha1.ShortLink) *ShortlinkClient {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, object := range objects {
+		builder = builder.WithObjects(object)
+	}
+
+	client := builder.Build()
+	return NewShortlinkClient(client, client, trace.NewNoopTracerProvider().Tracer("test"))
+}
+
+func newTestShortLink(name string, aliases ...string) *v1alpha1.ShortLink {
+	return &v1alpha1.ShortLink{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name)},
+		Spec:       v1alpha1.ShortLinkSpec{Target: "https://example.com", Aliases: aliases},
+	}
+}
+
+func TestClaimSlugsRefusesClaimedSlug(t *testing.T) {
+	holder := newTestShortLink("holder", "Shared")
+	c := newTestShortlinkClient(holder)
+
+	if err := c.ClaimSlugs(context.Background(), holder); err != nil {
+		t.Fatalf("ClaimSlugs() failed: %v", err)
+	}
+
+	var conflict *SlugConflictError
+	err := c.ClaimSlugs(context.Background(), newTestShortLink("other", "shared"))
+	if !errors.As(err, &conflict) || conflict.ShortLink != "holder" {
+		t.Fatalf("ClaimSlugs() = %v, want a conflict with holder", err)
+	}
+
+	if name, err := c.SlugHolder(context.Background(), "default", "SHARED"); err != nil || name != "holder" {
+		t.Errorf("SlugHolder() = %q, %v, want holder", name, err)
+	}
+}
+
+func TestClaimSlugsIsIdempotentAndAdopts(t *testing.T) {
+	shortlink := newTestShortLink("shortlink")
+	c := newTestShortlinkClient(shortlink)
+
+	// The API claims the slugs before the ShortLink exists
+	created := shortlink.DeepCopy()
+	created.UID = ""
+	if err := c.ClaimSlugs(context.Background(), created); err != nil {
+		t.Fatalf("ClaimSlugs() failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := c.ClaimSlugs(context.Background(), shortlink); err != nil {
+			t.Fatalf("ClaimSlugs() failed: %v", err)
+		}
+	}
+
+	claim := &corev1.ConfigMap{}
+	if err := c.reader.Get(context.Background(), slugClaimName("default", "shortlink"), claim); err != nil {
+		t.Fatalf("failed to get claim: %v", err)
+	}
+
+	if !metav1.IsControlledBy(claim, shortlink) {
+		t.Errorf("claim is not owned by the ShortLink: %v", claim.OwnerReferences)
+	}
+}
+
+func TestClaimSlugsTakesOverReleasedSlug(t *testing.T) {
+	holder := newTestShortLink("holder", "shared")
+	c := newTestShortlinkClient(holder)
+
+	if err := c.ClaimSlugs(context.Background(), holder); err != nil {
+		t.Fatalf("ClaimSlugs() failed: %v", err)
+	}
+
+	holder.Spec.Aliases = nil
+	if err := c.client.Update(context.Background(), holder); err != nil {
+		t.Fatalf("failed to update holder: %v", err)
+	}
+
+	if err := c.ClaimSlugs(context.Background(), newTestShortLink("other", "shared")); err != nil {
+		t.Fatalf("ClaimSlugs() of a released slug failed: %v", err)
+	}
+
+	if name, _ := c.SlugHolder(context.Background(), "default", "shared"); name != "other" {
+		t.Errorf("SlugHolder() = %q, want other", name)
+	}
+}
+
+func TestClaimSlugsHonoursPendingClaims(t *testing.T) {
+	c := newTestShortlinkClient()
+
+	pending := newTestShortLink("pending", "shared")
+	pending.UID = ""
+	if err := c.ClaimSlugs(context.Background(), pending); err != nil {
+		t.Fatalf("ClaimSlugs() failed: %v", err)
+	}
+
+	var conflict *SlugConflictError
+	if err := c.ClaimSlugs(context.Background(), newTestShortLink("other", "shared")); !errors.As(err, &conflict) {
+		t.Fatalf("ClaimSlugs() = %v, want a conflict while the ShortLink is being created", err)
+	}
+
+	// Once the grace period passed, the ShortLink must have failed to be created
+	claim := &corev1.ConfigMap{}
+	if err := c.reader.Get(context.Background(), slugClaimName("default", "shared"), claim); err != nil {
+		t.Fatalf("failed to get claim: %v", err)
+	}
+
+	claim.Data[slugClaimTimeKey] = time.Now().Add(-2 * slugClaimGracePeriod).UTC().Format(time.RFC3339)
+	if err := c.client.Update(context.Background(), claim); err != nil {
+		t.Fatalf("failed to update claim: %v", err)
+	}
+
+	if err := c.ClaimSlugs(context.Background(), newTestShortLink("other", "shared")); err != nil {
+		t.Fatalf("ClaimSlugs() of a stale claim failed: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// offeredContentTypes are the representations the API can render, in order of preference.
+// The first entry is used if the client did not send an Accept header or accepts none of them
+var offeredContentTypes = []string{
+	ContentTypeApplicationJSON,
+	ContentTypeTextPlain,
+}
+
+// mediaRange is a single entry of an Accept header, e.g. "text/*;q=0.5"
+type mediaRange struct {
+	mediaType string
+	subType   string
+	quality   float64
+}
+
+// negotiateContentType picks the best content type out of offeredContentTypes
+// for the given Accept header, honouring quality values and wildcards (RFC 9110, Section 12.5.1).
+func negotiateContentType(accept string) string {
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return offeredContentTypes[0]
+	}
+
+	bestOffer := offeredContentTypes[0]
+	bestQuality := 0.0
+
+	for _, offer := range offeredContentTypes {
+		if quality := matchQuality(ranges, offer); quality > bestQuality {
+			bestOffer = offer
+			bestQuality = quality
+		}
+	}
+
+	return bestOffer
+}
+
+// parseAccept parses an Accept header into its media ranges, ignoring invalid entries
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		// problem details are just a more specific flavour of JSON
+		if mediaType == ContentTypeProblemJSON {
+			mediaType = ContentTypeApplicationJSON
+		}
+
+		typ, subType, found := strings.Cut(mediaType, "/")
+		if !found {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{
+			mediaType: typ,
+			subType:   subType,
+			quality:   quality,
+		})
+	}
+
+	return ranges
+}
+
+// matchQuality returns the quality of the most specific media range matching contentType
+func matchQuality(ranges []mediaRange, contentType string) float64 {
+	typ, subType, _ := strings.Cut(contentType, "/")
+
+	quality := 0.0
+	specificity := -1
+
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.mediaType == typ && r.subType == subType:
+			s = 2
+		case r.mediaType == typ && r.subType == "*":
+			s = 1
+		case r.mediaType == "*" && r.subType == "*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			specificity = s
+			quality = r.quality
+		}
+	}
+
+	return quality
+}
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
 	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/model"
 	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/cedi/urlshortener/pkg/slug"
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 	"go.opentelemetry.io/otel/attribute"
@@ -25,22 +26,25 @@ import (
 // @Accept        application/json
 // @Produce       text/plain
 // @Produce       application/json
-// @Param         shortlink   path      string                 	false  					"the shortlink URL part (shortlink id)" example(home)
-// @Param         spec        body      v1alpha1.ShortLinkSpec 	true   					"shortlink spec"
-// @Success       200         {object}  int     				"Success"
+// @Param         shortlink   path      string                 	false  					"the shortlink URL part (shortlink id). Case-insensitive, must not be reserved" example(home)
+// @Param         spec        body      ShortLinkRequest 	true   					"shortlink spec and labels"
+// @Success       200         {object}  ShortLink 				"Success"
 // @Success       301         {object}  int     				"MovedPermanently"
 // @Success       302         {object}  int     				"Found"
 // @Success       307         {object}  int     				"TemporaryRedirect"
 // @Success       308         {object}  int     				"PermanentRedirect"
-// @Failure       401         {object}  int                     "Unauthorized"
-// @Failure       404         {object}  int     				"NotFound"
-// @Failure       500         {object}  int     				"InternalServerError"
+// @Failure       400         {object}  Problem                 "BadRequest"
+// @Failure       401         {object}  Problem                 "Unauthorized"
+// @Failure       403         {object}  Problem                 "Forbidden"
+// @Failure       409         {object}  Problem                 "Conflict"
+// @Failure       429         {object}  Problem                 "TooManyRequests"
+// @Failure       500         {object}  Problem                 "InternalServerError"
 // @Tags api/v1/
 // @Router /api/v1/shortlink/{shortlink} [post]
 // @Security bearerAuth
 func (s *ShortlinkController) HandleCreateShortLink(ct *gin.Context) {
 	shortlinkName := ct.Param("shortlink")
-	contentType := ct.Request.Header.Get("accept")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
 
 	ctx := ct.Request.Context()
 	span := trace.SpanFromContext(ctx)
@@ -61,11 +65,8 @@ func (s *ShortlinkController) HandleCreateShortLink(ct *gin.Context) {
 		zap.String("operation", "create"),
 	)
 
-	bearerToken := ct.Request.Header.Get("Authorization")
-	bearerToken = strings.TrimPrefix(bearerToken, "Bearer")
-	bearerToken = strings.TrimPrefix(bearerToken, "token")
-	if len(bearerToken) == 0 {
-		err := fmt.Errorf("no credentials provided")
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
 		observability.RecordError(ctx, span, log, err, "no credentials provided")
 		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
 		return
@@ -78,11 +79,8 @@ func (s *ShortlinkController) HandleCreateShortLink(ct *gin.Context) {
 		return
 	}
 
-	shortlink := v1alpha1.ShortLink{
-		ObjectMeta: v1.ObjectMeta{
-			Name: shortlinkName,
-		},
-		Spec: v1alpha1.ShortLinkSpec{},
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
 	}
 
 	jsonData, err := io.ReadAll(ct.Request.Body)
@@ -92,25 +90,50 @@ func (s *ShortlinkController) HandleCreateShortLink(ct *gin.Context) {
 		return
 	}
 
-	if err := json.Unmarshal([]byte(jsonData), &shortlink.Spec); err != nil {
+	shortlinkRequest := ShortLinkRequest{}
+	if err := json.Unmarshal([]byte(jsonData), &shortlinkRequest); err != nil {
 		observability.RecordError(ctx, span, log, err, "Failed to read spec-json")
-		ginReturnError(ct, http.StatusInternalServerError, contentType, err.Error())
+		ginReturnError(ct, http.StatusBadRequest, contentType, err.Error())
+		return
+	}
+
+	if err := validateLabels(shortlinkRequest.Labels); err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid labels")
+		ginReturnError(ct, http.StatusBadRequest, contentType, err.Error())
+		return
+	}
+
+	shortlink := v1alpha1.ShortLink{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   slug.ObjectName(shortlinkName),
+			Labels: shortlinkRequest.Labels,
+		},
+		Spec: shortlinkRequest.ShortLinkSpec,
+	}
+
+	// The slug is only stored if it isn't a valid object name and is therefore mapped to a different name
+	shortlink.Spec.Slug = ""
+	if shortlink.Name != shortlinkName {
+		shortlink.Spec.Slug = shortlinkName
+	}
+
+	if statusCode, err := s.validateSlugs(ctx, &shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid slug")
+		ginReturnError(ct, statusCode, contentType, err.Error())
 		return
 	}
 
 	if err := s.authenticatedClient.Create(ctx, githubUser.Login, &shortlink); err != nil {
 		observability.RecordError(ctx, span, log, err, "Failed to create ShortLink")
-		ginReturnError(ct, http.StatusInternalServerError, contentType, err.Error())
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
 		return
 	}
 
-	if contentType == ContentTypeTextPlain {
-		ct.Data(http.StatusOK, contentType, []byte(fmt.Sprintf("%s: %s\n", shortlink.Name, shortlink.Spec.Target)))
-	} else if contentType == ContentTypeApplicationJSON {
-		ct.JSON(http.StatusOK, ShortLink{
-			Name:   shortlink.Name,
-			Spec:   shortlink.Spec,
-			Status: shortlink.Status,
-		})
-	}
+	s.recordAudit(ctx, ct, &shortlink, model.AuditOperationCreate, githubUser.Login, nil, &shortlink.Spec)
+
+	setETag(ct, &shortlink)
+	ginReturn(ct, http.StatusOK, contentType,
+		fmt.Sprintf("%s: %s\n", shortlink.Name, shortlink.Spec.Target),
+		newShortLink(&shortlink),
+	)
 }
@@ -1,10 +1,9 @@
 package controller
 
 import (
-	"fmt"
 	"net/http"
-	"strings"
 
+	"github.com/cedi/urlshortener/pkg/model"
 	"github.com/cedi/urlshortener/pkg/observability"
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
@@ -22,15 +21,17 @@ import (
 // @Produce       application/json
 // @Param         shortlink   path      string                 true   "the shortlink URL part (shortlink id)" example(home)
 // @Success       200         {object}  int     "Success"
-// @Failure       401         {object}  int     "Unauthorized"
-// @Failure       404         {object}  int     "NotFound"
-// @Failure       500         {object}  int     "InternalServerError"
+// @Failure       401         {object}  Problem   "Unauthorized"
+// @Failure       403         {object}  Problem   "Forbidden"
+// @Failure       404         {object}  Problem   "NotFound"
+// @Failure       429         {object}  Problem   "TooManyRequests"
+// @Failure       500         {object}  Problem   "InternalServerError"
 // @Tags api/v1/
 // @Router /api/v1/shortlink/{shortlink} [delete]
 // @Security bearerAuth
 func (s *ShortlinkController) HandleDeleteShortLink(ct *gin.Context) {
 	shortlinkName := ct.Param("shortlink")
-	contentType := ct.Request.Header.Get("accept")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
 
 	ctx := ct.Request.Context()
 	span := trace.SpanFromContext(ctx)
@@ -51,11 +52,8 @@ func (s *ShortlinkController) HandleDeleteShortLink(ct *gin.Context) {
 		zap.String("operation", "delete"),
 	)
 
-	bearerToken := ct.Request.Header.Get("Authorization")
-	bearerToken = strings.TrimPrefix(bearerToken, "Bearer")
-	bearerToken = strings.TrimPrefix(bearerToken, "token")
-	if len(bearerToken) == 0 {
-		err := fmt.Errorf("no credentials provided")
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
 		observability.RecordError(ctx, span, log, err, "no credentials provided")
 		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
 		return
@@ -68,17 +66,15 @@ func (s *ShortlinkController) HandleDeleteShortLink(ct *gin.Context) {
 		return
 	}
 
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
+	}
+
 	shortlink, err := s.authenticatedClient.Get(ctx, githubUser.Login, shortlinkName)
 	if err != nil {
 		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
 
-		statusCode := http.StatusInternalServerError
-
-		if strings.Contains(err.Error(), "not found") {
-			statusCode = http.StatusNotFound
-		}
-
-		ginReturnError(ct, statusCode, contentType, err.Error())
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
 		return
 	}
 
@@ -89,15 +85,11 @@ func (s *ShortlinkController) HandleDeleteShortLink(ct *gin.Context) {
 	}
 
 	if err := s.authenticatedClient.Delete(ctx, githubUser.Login, shortlink); err != nil {
-		statusCode := http.StatusInternalServerError
-
-		if strings.Contains(err.Error(), "not found") {
-			statusCode = http.StatusNotFound
-		}
-
 		observability.RecordError(ctx, span, log, err, "Failed to delete ShortLink")
 
-		ginReturnError(ct, statusCode, contentType, err.Error())
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
 		return
 	}
+
+	s.recordAudit(ctx, ct, shortlink, model.AuditOperationDelete, githubUser.Login, &shortlink.Spec, nil)
 }
@@ -1,9 +1,7 @@
 package controller
 
 import (
-	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/cedi/urlshortener/pkg/observability"
 	"github.com/gin-gonic/gin"
@@ -22,15 +20,17 @@ import (
 // @Produce       application/json
 // @Param         shortlink   path      string    false          "the shortlink URL part (shortlink id)" example(home)
 // @Success       200         {object}  ShortLink "Success"
-// @Failure       401         {object}  int       "Unauthorized"
-// @Failure       404         {object}  int       "NotFound"
-// @Failure       500         {object}  int       "InternalServerError"
+// @Failure       401         {object}  Problem   "Unauthorized"
+// @Failure       403         {object}  Problem   "Forbidden"
+// @Failure       404         {object}  Problem   "NotFound"
+// @Failure       429         {object}  Problem   "TooManyRequests"
+// @Failure       500         {object}  Problem   "InternalServerError"
 // @Tags api/v1/
 // @Router /api/v1/shortlink/{shortlink} [get]
 // @Security bearerAuth
 func (s *ShortlinkController) HandleGetShortLink(ct *gin.Context) {
 	shortlinkName := ct.Param("shortlink")
-	contentType := ct.Request.Header.Get("accept")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
 
 	ctx := ct.Request.Context()
 	span := trace.SpanFromContext(ctx)
@@ -51,11 +51,8 @@ func (s *ShortlinkController) HandleGetShortLink(ct *gin.Context) {
 		zap.String("operation", "create"),
 	)
 
-	bearerToken := ct.Request.Header.Get("Authorization")
-	bearerToken = strings.TrimPrefix(bearerToken, "Bearer")
-	bearerToken = strings.TrimPrefix(bearerToken, "token")
-	if len(bearerToken) == 0 {
-		err := fmt.Errorf("no credentials provided")
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
 		observability.RecordError(ctx, span, log, err, "no credentials provided")
 		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
 		return
@@ -68,27 +65,21 @@ func (s *ShortlinkController) HandleGetShortLink(ct *gin.Context) {
 		return
 	}
 
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
+	}
+
 	shortlink, err := s.authenticatedClient.Get(ctx, githubUser.Login, shortlinkName)
 	if err != nil {
 		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
 
-		statusCode := http.StatusInternalServerError
-
-		if strings.Contains(err.Error(), "not found") {
-			statusCode = http.StatusNotFound
-		}
-
-		ginReturnError(ct, statusCode, contentType, err.Error())
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
 		return
 	}
 
-	if contentType == ContentTypeTextPlain {
-		ct.Data(http.StatusOK, contentType, []byte(shortlink.Spec.Target))
-	} else if contentType == ContentTypeApplicationJSON {
-		ct.JSON(http.StatusOK, ShortLink{
-			Name:   shortlink.Name,
-			Spec:   shortlink.Spec,
-			Status: shortlink.Status,
-		})
-	}
+	setETag(ct, shortlink)
+	ginReturn(ct, http.StatusOK, contentType,
+		shortlink.Spec.Target,
+		newShortLink(shortlink),
+	)
 }
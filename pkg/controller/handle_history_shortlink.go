@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// HandleGetShortLinkHistory returns the audit trail of a shortlink
+// @BasePath /api/v1/
+// @Summary       get the history of a shortlink
+// @Schemes       http https
+// @Description   get all recorded create and update operations of a shortlink, oldest first
+// @Produce       text/plain
+// @Produce       application/json
+// @Param         shortlink   path      string         true   "the shortlink URL part (shortlink id)" example(home)
+// @Success       200         {object}  []model.AuditEntry "Success"
+// @Failure       401         {object}  Problem   "Unauthorized"
+// @Failure       403         {object}  Problem   "Forbidden"
+// @Failure       404         {object}  Problem   "NotFound"
+// @Failure       429         {object}  Problem   "TooManyRequests"
+// @Failure       500         {object}  Problem   "InternalServerError"
+// @Tags api/v1/
+// @Router /api/v1/shortlink/{shortlink}/history [get]
+// @Security bearerAuth
+func (s *ShortlinkController) HandleGetShortLinkHistory(ct *gin.Context) {
+	shortlinkName := ct.Param("shortlink")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = s.tracer.Start(ctx, "ShortlinkController.HandleGetShortLinkHistory")
+		defer span.End()
+	}
+
+	span.SetAttributes(
+		attribute.String("shortlink", shortlinkName),
+		attribute.String("content_type", contentType),
+		attribute.String("referrer", ct.Request.Referer()),
+	)
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", shortlinkName),
+		zap.String("operation", "history"),
+	)
+
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "no credentials provided")
+		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
+		return
+	}
+
+	githubUser, err := getGitHubUserInfo(ctx, bearerToken)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "GitHub User Info invalid")
+		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
+		return
+	}
+
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
+	}
+
+	shortlink, err := s.authenticatedClient.Get(ctx, githubUser.Login, shortlinkName)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
+		return
+	}
+
+	history, err := s.auditClient.History(ctx, shortlink)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink history")
+		ginReturnError(ct, http.StatusInternalServerError, contentType, err.Error())
+		return
+	}
+
+	var text strings.Builder
+	for _, entry := range history {
+		text.WriteString(fmt.Sprintf("%s %s by %s from %s\n", entry.Timestamp.Format(time.RFC3339), entry.Operation, entry.Actor, entry.SourceIP))
+		for _, change := range entry.Changes {
+			text.WriteString(fmt.Sprintf("  %s: %v -> %v\n", change.Field, change.Before, change.After))
+		}
+	}
+
+	ginReturn(ct, http.StatusOK, contentType, text.String(), history)
+}
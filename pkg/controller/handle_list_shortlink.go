@@ -3,32 +3,59 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	shortlinkClient "github.com/cedi/urlshortener/pkg/client"
 	"github.com/cedi/urlshortener/pkg/observability"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
-// HandleListShortLink handles the listing of
+const (
+	// ContinueHeader is the response header carrying the token to fetch the next page of a list
+	ContinueHeader = "X-Continue"
+
+	// maxListLimit is the largest page size a client can request
+	maxListLimit = 500
+)
+
+// HandleListShortLink handles the listing of shortlinks
 // @BasePath /api/v1/
 // @Summary       list shortlinks
 // @Schemes       http https
 // @Description   list shortlinks
 // @Produce       text/plain
 // @Produce       application/json
+// @Param         limit          query  int     false  "maximum number of shortlinks per page"
+// @Param         continue       query  string  false  "continue token returned in the X-Continue header of the previous page"
+// @Param         owner          query  string  false  "only list shortlinks owned by this user"
+// @Param         domain         query  string  false  "only list shortlinks whose target is on this domain"
+// @Param         label          query  string  false  "Kubernetes label selector, e.g. team=platform"
+// @Param         tag            query  []string false "only list shortlinks tagged with all of the given tags" collectionFormat(multi)
+// @Param         createdAfter   query  string  false  "only list shortlinks created after this RFC 3339 date-time"
+// @Param         createdBefore  query  string  false  "only list shortlinks created before this RFC 3339 date-time"
+// @Param         modifiedAfter  query  string  false  "only list shortlinks modified after this RFC 3339 date-time"
+// @Param         modifiedBefore query  string  false  "only list shortlinks modified before this RFC 3339 date-time"
+// @Param         sort           query  string  false  "sort by name, count or lastModified. Prefix with - to sort descending"
+// @Param         q              query  string  false  "full-text search over name, target and description"
 // @Success       200         {object} []ShortLink "Success"
-// @Failure       401         {object} int         "Unauthorized"
-// @Failure       404         {object} int         "NotFound"
-// @Failure       500         {object} int         "InternalServerError"
+// @Header        200         {string} X-Continue  "continue token to fetch the next page"
+// @Failure       400         {object}  Problem   "BadRequest"
+// @Failure       401         {object}  Problem   "Unauthorized"
+// @Failure       429         {object}  Problem   "TooManyRequests"
+// @Failure       500         {object}  Problem   "InternalServerError"
 // @Tags api/v1/
 // @Router /api/v1/shortlink/ [get]
 // @Security bearerAuth
 func (s *ShortlinkController) HandleListShortLink(ct *gin.Context) {
-	contentType := ct.Request.Header.Get("accept")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
 
 	// Extract span from the request context
 	ctx := ct.Request.Context()
@@ -36,7 +63,7 @@ func (s *ShortlinkController) HandleListShortLink(ct *gin.Context) {
 
 	// Check if the span was sampled and is recording the data
 	if !span.IsRecording() {
-		ctx, span = s.tracer.Start(ctx, "ShortlinkController.HandleDeleteShortLink")
+		ctx, span = s.tracer.Start(ctx, "ShortlinkController.HandleListShortLink")
 		defer span.End()
 	}
 
@@ -47,11 +74,8 @@ func (s *ShortlinkController) HandleListShortLink(ct *gin.Context) {
 
 	log := otelzap.L().Sugar().With(zap.String("operation", "list"))
 
-	bearerToken := ct.Request.Header.Get("Authorization")
-	bearerToken = strings.TrimPrefix(bearerToken, "Bearer")
-	bearerToken = strings.TrimPrefix(bearerToken, "token")
-	if len(bearerToken) == 0 {
-		err := fmt.Errorf("no credentials provided")
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
 		observability.RecordError(ctx, span, log, err, "no credentials provided")
 		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
 		return
@@ -64,37 +88,106 @@ func (s *ShortlinkController) HandleListShortLink(ct *gin.Context) {
 		return
 	}
 
-	shortlinkList, err := s.authenticatedClient.List(ctx, githubUser.Login)
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
+	}
+
+	listOptions, err := parseListOptions(ct)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid list options")
+		ginReturnError(ct, http.StatusBadRequest, contentType, err.Error())
+		return
+	}
+
+	shortlinkList, err := s.authenticatedClient.List(ctx, githubUser.Login, listOptions)
 	if err != nil {
 		observability.RecordError(ctx, span, log, err, "Failed to list ShortLink")
 
-		statusCode := http.StatusInternalServerError
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
+		return
+	}
+
+	targetList := make([]ShortLink, len(shortlinkList.Items))
+
+	for idx := range shortlinkList.Items {
+		targetList[idx] = newShortLink(&shortlinkList.Items[idx])
+	}
+
+	shortLinks := ""
+	for _, shortlink := range targetList {
+		shortLinks += fmt.Sprintf("%s: %s\n", shortlink.Name, shortlink.Spec.Target)
+	}
+
+	if shortlinkList.Continue != "" {
+		ct.Header(ContinueHeader, shortlinkList.Continue)
+	}
+
+	ginReturn(ct, http.StatusOK, contentType, shortLinks, targetList)
+}
 
-		if strings.Contains(err.Error(), "not found") {
-			statusCode = http.StatusNotFound
+// parseListOptions parses the query parameters of the list request
+func parseListOptions(ct *gin.Context) (*shortlinkClient.ShortLinkListOptions, error) {
+	opts := &shortlinkClient.ShortLinkListOptions{
+		Continue:     ct.Query("continue"),
+		Owner:        ct.Query("owner"),
+		Tags:         ct.QueryArray("tag"),
+		TargetDomain: ct.Query("domain"),
+		Query:        ct.Query("q"),
+	}
+
+	if limit := ct.Query("limit"); limit != "" {
+		l, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || l < 1 || l > maxListLimit {
+			return nil, fmt.Errorf("limit must be a number between 1 and %d", maxListLimit)
 		}
 
-		ginReturnError(ct, statusCode, contentType, err.Error())
-		return
+		opts.Limit = l
 	}
 
-	targetList := make([]ShortLink, len(shortlinkList.Items))
+	if label := ct.Query("label"); label != "" {
+		selector, err := labels.Parse(label)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid label selector")
+		}
+
+		opts.LabelSelector = selector
+	}
+
+	timeFilters := map[string]**time.Time{
+		"createdAfter":   &opts.CreatedAfter,
+		"createdBefore":  &opts.CreatedBefore,
+		"modifiedAfter":  &opts.ModifiedAfter,
+		"modifiedBefore": &opts.ModifiedBefore,
+	}
 
-	for idx, shortlink := range shortlinkList.Items {
-		targetList[idx] = ShortLink{
-			Name:   shortlink.ObjectMeta.Name,
-			Spec:   shortlink.Spec,
-			Status: shortlink.Status,
+	for param, filter := range timeFilters {
+		value := ct.Query(param)
+		if value == "" {
+			continue
 		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s must be an RFC 3339 date-time", param)
+		}
+
+		*filter = &t
 	}
 
-	if contentType == ContentTypeApplicationJSON {
-		ct.JSON(http.StatusOK, targetList)
-	} else if contentType == ContentTypeTextPlain {
-		shortLinks := ""
-		for _, shortlink := range targetList {
-			shortLinks += fmt.Sprintf("%s: %s\n", shortlink.Name, shortlink.Spec.Target)
+	if sortBy := ct.Query("sort"); sortBy != "" {
+		opts.SortDescending = strings.HasPrefix(sortBy, "-")
+		opts.SortBy = strings.TrimPrefix(sortBy, "-")
+
+		switch opts.SortBy {
+		case shortlinkClient.SortByName, shortlinkClient.SortByCount, shortlinkClient.SortByLastModified:
+		default:
+			return nil, fmt.Errorf("sort must be one of %s, %s or %s",
+				shortlinkClient.SortByName,
+				shortlinkClient.SortByCount,
+				shortlinkClient.SortByLastModified,
+			)
 		}
-		ct.Data(http.StatusOK, contentType, []byte(shortLinks))
 	}
+
+	return opts, nil
 }
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/cedi/urlshortener/pkg/model"
+	"github.com/cedi/urlshortener/pkg/observability"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// HandlePatchShortLink handles the partial update of a shortlink
+// @BasePath /api/v1/
+// @Summary       patch existing shortlink
+// @Schemes       http https
+// @Description   partially update a shortlink using a JSON Merge Patch (RFC 7386) or a JSON Patch (RFC 6902) of the ShortLinkRequest document (spec and labels)
+// @Accept        application/merge-patch+json
+// @Accept        application/json-patch+json
+// @Produce       text/plain
+// @Produce       application/json
+// @Param         shortlink   path      string    true   "the shortlink URL part (shortlink id)" example(home)
+// @Param         patch       body      string    true   "patch of the shortlink spec"
+// @Param         If-Match    header    string    false  "only update if the ETag of the shortlink matches"
+// @Success       200         {object}  ShortLink "Success"
+// @Failure       400         {object}  Problem   "BadRequest"
+// @Failure       401         {object}  Problem   "Unauthorized"
+// @Failure       403         {object}  Problem   "Forbidden"
+// @Failure       404         {object}  Problem   "NotFound"
+// @Failure       409         {object}  Problem   "Conflict"
+// @Failure       412         {object}  Problem   "PreconditionFailed"
+// @Failure       415         {object}  Problem   "UnsupportedMediaType"
+// @Failure       429         {object}  Problem   "TooManyRequests"
+// @Failure       500         {object}  Problem   "InternalServerError"
+// @Tags api/v1/
+// @Router /api/v1/shortlink/{shortlink} [patch]
+// @Security bearerAuth
+func (s *ShortlinkController) HandlePatchShortLink(ct *gin.Context) {
+	shortlinkName := ct.Param("shortlink")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = s.tracer.Start(ctx, "ShortlinkController.HandlePatchShortLink")
+		defer span.End()
+	}
+
+	span.SetAttributes(
+		attribute.String("shortlink", shortlinkName),
+		attribute.String("content_type", contentType),
+		attribute.String("referrer", ct.Request.Referer()),
+	)
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", shortlinkName),
+		zap.String("operation", "patch"),
+	)
+
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "no credentials provided")
+		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
+		return
+	}
+
+	githubUser, err := getGitHubUserInfo(ctx, bearerToken)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "GitHub User Info invalid")
+		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
+		return
+	}
+
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
+	}
+
+	patchType, _, err := mime.ParseMediaType(ct.Request.Header.Get("Content-Type"))
+	if err != nil || (patchType != ContentTypeMergePatchJSON && patchType != ContentTypeJSONPatchJSON) {
+		err := fmt.Errorf("unsupported patch content type, use %s or %s", ContentTypeMergePatchJSON, ContentTypeJSONPatchJSON)
+		observability.RecordError(ctx, span, log, err, "Unsupported patch type")
+		ginReturnError(ct, http.StatusUnsupportedMediaType, contentType, err.Error())
+		return
+	}
+
+	shortlink, err := s.authenticatedClient.Get(ctx, githubUser.Login, shortlinkName)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
+		return
+	}
+
+	if !ifMatchSatisfied(ct, shortlink) {
+		err := fmt.Errorf("shortlink %s was modified, current ETag is %q", shortlink.Name, shortlink.ResourceVersion)
+		observability.RecordError(ctx, span, log, err, "Precondition failed")
+		ginReturnError(ct, http.StatusPreconditionFailed, contentType, err.Error())
+		return
+	}
+
+	patch, err := io.ReadAll(ct.Request.Body)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to read request-body")
+		ginReturnError(ct, http.StatusInternalServerError, contentType, err.Error())
+		return
+	}
+
+	patchedRequest, err := patchShortLinkRequest(ShortLinkRequest{ShortLinkSpec: shortlink.Spec, Labels: shortlink.Labels}, patchType, patch)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to apply patch")
+		ginReturnError(ct, http.StatusBadRequest, contentType, err.Error())
+		return
+	}
+
+	if err := validateLabels(patchedRequest.Labels); err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid labels")
+		ginReturnError(ct, http.StatusBadRequest, contentType, err.Error())
+		return
+	}
+
+	before := shortlink.Spec.DeepCopy()
+	shortlink.Spec = patchedRequest.ShortLinkSpec
+	shortlink.Labels = patchedRequest.Labels
+
+	if statusCode, err := s.validateSlugs(ctx, shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid slug")
+		ginReturnError(ct, statusCode, contentType, err.Error())
+		return
+	}
+
+	if err := s.authenticatedClient.Update(ctx, githubUser.Login, shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to update ShortLink")
+		ginReturnError(ct, statusCodeForWriteError(ct, err), contentType, err.Error())
+		return
+	}
+
+	s.recordAudit(ctx, ct, shortlink, model.AuditOperationUpdate, githubUser.Login, before, &shortlink.Spec)
+
+	setETag(ct, shortlink)
+	ginReturn(ct, http.StatusOK, contentType,
+		fmt.Sprintf("%s: %s\n", shortlink.Name, shortlink.Spec.Target),
+		newShortLink(shortlink),
+	)
+}
+
+// patchShortLinkRequest applies a JSON Merge Patch or a JSON Patch to the JSON representation of request
+func patchShortLinkRequest(request ShortLinkRequest, patchType string, patch []byte) (*ShortLinkRequest, error) {
+	original, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+
+	switch patchType {
+	case ContentTypeMergePatchJSON:
+		if patched, err = jsonpatch.MergePatch(original, patch); err != nil {
+			return nil, err
+		}
+
+	case ContentTypeJSONPatchJSON:
+		jsonPatch, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, err
+		}
+
+		if patched, err = jsonPatch.Apply(original); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported patch type %s", patchType)
+	}
+
+	patchedRequest := &ShortLinkRequest{}
+	if err := json.Unmarshal(patched, patchedRequest); err != nil {
+		return nil, err
+	}
+
+	return patchedRequest, nil
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// HandleNativeRedirect is a middleware redirecting requests to the source host of a native Redirect.
+// Requests to any other host, including the hosts of the urlshortener itself, are passed on to the next handler
+func (r *RedirectController) HandleNativeRedirect(ct *gin.Context) {
+	host := ct.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	// Without the namespace or the hosts of the urlshortener native Redirects are disabled, as a Redirect could take
+	// over the urlshortener otherwise. The hosts of the urlshortener itself are never redirected
+	if r.namespace == "" || len(r.ownHosts) == 0 || slices.Contains(r.ownHosts, strings.ToLower(host)) {
+		ct.Next()
+		return
+	}
+
+	ctx := ct.Request.Context()
+
+	redirect, err := r.client.GetNativeBySource(ctx, r.namespace, host)
+	if err != nil {
+		log := otelzap.L().Sugar().With(zap.String("host", host),
+			zap.String("operation", "redirect"),
+		)
+		observability.RecordError(ctx, trace.SpanFromContext(ctx), log, err, "Failed to look up native Redirect")
+	}
+
+	if redirect == nil {
+		ct.Next()
+		return
+	}
+
+	// Native redirects are rate limited like shortlinks
+	r.rateLimiter.LimitRedirect(ct)
+	if ct.IsAborted() {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = r.tracer.Start(ctx, "RedirectController.HandleNativeRedirect")
+		defer span.End()
+	}
+
+	span.SetAttributes(
+		attribute.String("redirect", redirect.Name),
+		attribute.String("host", host),
+		attribute.String("referrer", ct.Request.Referer()),
+	)
+
+	log := otelzap.L().Sugar().With(zap.String("redirect", redirect.Name),
+		zap.String("operation", "redirect"),
+	)
+
+	target, code, err := r.resolver.ResolveTarget(redirect, ct.Request.URL)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to resolve Redirect target")
+		ct.HTML(http.StatusInternalServerError, "500.html", gin.H{})
+		ct.Abort()
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("Target", target),
+		attribute.Int("InvocationCount", redirect.Status.Count),
+	)
+
+	ct.Redirect(code, target)
+	ct.Abort()
+
+	// Increase hit counter
+	if err := r.client.IncrementInvocationCount(ctx, redirect); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to increment invocation count")
+	}
+}
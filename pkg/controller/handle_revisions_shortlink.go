@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	shortlinkClient "github.com/cedi/urlshortener/pkg/client"
+	"github.com/cedi/urlshortener/pkg/model"
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// HandleListShortLinkRevisions returns the spec revisions of a shortlink
+// @BasePath /api/v1/
+// @Summary       list the revisions of a shortlink
+// @Schemes       http https
+// @Description   list the recorded spec revisions of a shortlink, oldest first. The latest revision is the current spec
+// @Produce       text/plain
+// @Produce       application/json
+// @Param         shortlink   path      string         true   "the shortlink URL part (shortlink id)" example(home)
+// @Success       200         {object}  []model.Revision "Success"
+// @Failure       401         {object}  Problem   "Unauthorized"
+// @Failure       403         {object}  Problem   "Forbidden"
+// @Failure       404         {object}  Problem   "NotFound"
+// @Failure       429         {object}  Problem   "TooManyRequests"
+// @Failure       500         {object}  Problem   "InternalServerError"
+// @Tags api/v1/
+// @Router /api/v1/shortlink/{shortlink}/revisions [get]
+// @Security bearerAuth
+func (s *ShortlinkController) HandleListShortLinkRevisions(ct *gin.Context) {
+	shortlinkName := ct.Param("shortlink")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = s.tracer.Start(ctx, "ShortlinkController.HandleListShortLinkRevisions")
+		defer span.End()
+	}
+
+	span.SetAttributes(
+		attribute.String("shortlink", shortlinkName),
+		attribute.String("content_type", contentType),
+		attribute.String("referrer", ct.Request.Referer()),
+	)
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", shortlinkName),
+		zap.String("operation", "revisions"),
+	)
+
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "no credentials provided")
+		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
+		return
+	}
+
+	githubUser, err := getGitHubUserInfo(ctx, bearerToken)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "GitHub User Info invalid")
+		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
+		return
+	}
+
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
+	}
+
+	shortlink, err := s.authenticatedClient.Get(ctx, githubUser.Login, shortlinkName)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
+		return
+	}
+
+	revisions, err := shortlinkClient.GetRevisions(shortlink)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink revisions")
+		ginReturnError(ct, http.StatusInternalServerError, contentType, err.Error())
+		return
+	}
+
+	var text strings.Builder
+	for _, revision := range revisions {
+		text.WriteString(fmt.Sprintf("%d %s %s: %s\n", revision.Revision, revision.Timestamp.Format(time.RFC3339), revision.ChangedBy, revision.Spec.Target))
+	}
+
+	ginReturn(ct, http.StatusOK, contentType, text.String(), revisions)
+}
+
+// HandleRollbackShortLink restores a previous revision of a shortlink
+// @BasePath /api/v1/
+// @Summary       rollback shortlink
+// @Schemes       http https
+// @Description   restore the spec of a previous revision of a shortlink. The owners of the shortlink are kept
+// @Produce       text/plain
+// @Produce       application/json
+// @Param         shortlink   path      string    true   "the shortlink URL part (shortlink id)" example(home)
+// @Param         revision    query     int       true   "the revision to restore"
+// @Param         If-Match    header    string    false  "only rollback if the ETag of the shortlink matches"
+// @Success       200         {object}  ShortLink "Success"
+// @Failure       400         {object}  Problem   "BadRequest"
+// @Failure       401         {object}  Problem   "Unauthorized"
+// @Failure       403         {object}  Problem   "Forbidden"
+// @Failure       404         {object}  Problem   "NotFound"
+// @Failure       409         {object}  Problem   "Conflict"
+// @Failure       412         {object}  Problem   "PreconditionFailed"
+// @Failure       429         {object}  Problem   "TooManyRequests"
+// @Failure       500         {object}  Problem   "InternalServerError"
+// @Tags api/v1/
+// @Router /api/v1/shortlink/{shortlink}/rollback [post]
+// @Security bearerAuth
+func (s *ShortlinkController) HandleRollbackShortLink(ct *gin.Context) {
+	shortlinkName := ct.Param("shortlink")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = s.tracer.Start(ctx, "ShortlinkController.HandleRollbackShortLink")
+		defer span.End()
+	}
+
+	span.SetAttributes(
+		attribute.String("shortlink", shortlinkName),
+		attribute.String("content_type", contentType),
+		attribute.String("referrer", ct.Request.Referer()),
+	)
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", shortlinkName),
+		zap.String("operation", "rollback"),
+	)
+
+	revision, err := strconv.ParseInt(ct.Query("revision"), 10, 64)
+	if err != nil || revision < 1 {
+		err := fmt.Errorf("invalid revision %q: must be a positive integer", ct.Query("revision"))
+		observability.RecordError(ctx, span, log, err, "Invalid revision")
+		ginReturnError(ct, http.StatusBadRequest, contentType, err.Error())
+		return
+	}
+
+	span.SetAttributes(attribute.Int64("revision", revision))
+
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "no credentials provided")
+		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
+		return
+	}
+
+	githubUser, err := getGitHubUserInfo(ctx, bearerToken)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "GitHub User Info invalid")
+		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
+		return
+	}
+
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
+	}
+
+	shortlink, err := s.authenticatedClient.Get(ctx, githubUser.Login, shortlinkName)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
+		return
+	}
+
+	if !ifMatchSatisfied(ct, shortlink) {
+		err := fmt.Errorf("shortlink %s was modified, current ETag is %q", shortlink.Name, shortlink.ResourceVersion)
+		observability.RecordError(ctx, span, log, err, "Precondition failed")
+		ginReturnError(ct, http.StatusPreconditionFailed, contentType, err.Error())
+		return
+	}
+
+	before := shortlink.Spec.DeepCopy()
+
+	if err := s.authenticatedClient.Rollback(ctx, githubUser.Login, shortlink, revision); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to rollback ShortLink")
+		ginReturnError(ct, statusCodeForWriteError(ct, err), contentType, err.Error())
+		return
+	}
+
+	s.recordAudit(ctx, ct, shortlink, model.AuditOperationRollback, githubUser.Login, before, &shortlink.Spec)
+
+	setETag(ct, shortlink)
+	ginReturn(ct, http.StatusOK, contentType,
+		fmt.Sprintf("%s: %s\n", shortlink.Name, shortlink.Spec.Target),
+		newShortLink(shortlink),
+	)
+}
@@ -1,16 +1,21 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/crawler"
 	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/cedi/urlshortener/pkg/slug"
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 // HandleShortlink handles the shortlink and redirects according to the configuration
@@ -30,6 +35,8 @@ import (
 // @Success       307         {object}  int     "TemporaryRedirect"
 // @Success       308         {object}  int     "PermanentRedirect"
 // @Failure       404         {object}  int     "NotFound"
+// @Failure       410         {object}  int     "Gone"
+// @Failure       429         {object}  int     "TooManyRequests"
 // @Failure       500         {object}  int     "InternalServerError"
 // @Tags default
 // @Router /{shortlink} [get]
@@ -56,13 +63,26 @@ func (s *ShortlinkController) HandleShortLink(ct *gin.Context) {
 
 	ct.Header("Cache-Control", "public, max-age=900, stale-if-error=3600") // max-age = 15min; stale-if-error = 1h
 
-	shortlink, err := s.client.Get(ctx, shortlinkName)
+	if s.crawlerPreview {
+		// Crawlers get a different response than browsers
+		ct.Header("Vary", "User-Agent")
+	}
+
+	shortlink, err := s.client.GetBySlug(ctx, shortlinkName)
+	if k8serrors.IsNotFound(err) {
+		shortlink, err = s.getByVariant(ctx, shortlinkName, err)
+	}
+
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if k8serrors.IsNotFound(err) {
 			observability.RecordError(ctx, span, log, err, "Path not found")
 			span.SetAttributes(attribute.String("path", ct.Request.URL.Path))
 
-			ct.HTML(http.StatusNotFound, "404.html", gin.H{})
+			s.missed.record(shortlinkName)
+
+			ct.HTML(http.StatusNotFound, "404.html", gin.H{
+				"suggestions": s.suggest(ctx, shortlinkName),
+			})
 		} else {
 			observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
 			ct.HTML(http.StatusInternalServerError, "500.html", gin.H{})
@@ -70,6 +90,12 @@ func (s *ShortlinkController) HandleShortLink(ct *gin.Context) {
 		return
 	}
 
+	if shortlink.IsExpired() {
+		observability.RecordInfo(ctx, span, log, "ShortLink expired")
+		ct.HTML(http.StatusGone, "404.html", gin.H{})
+		return
+	}
+
 	span.SetAttributes(
 		attribute.String("Target", shortlink.Spec.Target),
 		attribute.Int64("RedirectAfter", shortlink.Spec.RedirectAfter),
@@ -87,7 +113,43 @@ func (s *ShortlinkController) HandleShortLink(ct *gin.Context) {
 		))
 	}
 
-	if shortlink.Spec.Code != 200 {
+	defaults, err := s.queryDefaultsClient.Get(ctx, shortlink.Namespace)
+	if err != nil {
+		// The shortlink still works without the defaults
+		observability.RecordError(ctx, span, log, err, "Failed to get default query parameters")
+	}
+
+	if target, err = addQueryParameters(target, shortlink, defaults); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to add query parameters to target")
+		ct.HTML(http.StatusInternalServerError, "500.html", gin.H{})
+		return
+	}
+
+	openGraph := shortlink.GetOpenGraph()
+	if openGraph.Title == "" {
+		openGraph.Title = shortlink.GetSlug()
+	}
+
+	bot, isBot := crawler.Detect(ct.Request)
+	if isBot {
+		span.SetAttributes(
+			attribute.String("crawler", bot.Name),
+			attribute.Bool("crawlerPreview", bot.Preview),
+		)
+	}
+
+	if isBot && bot.Preview && s.crawlerPreview {
+		// Show the chat app or social network the link is shared on what the shortlink is about,
+		// rather than the preview of the redirect page
+		ct.HTML(
+			http.StatusOK,
+			"preview.html",
+			gin.H{
+				"redirectTo": target,
+				"openGraph":  openGraph,
+			},
+		)
+	} else if shortlink.Spec.Code != 200 {
 		// Redirect
 		ct.Redirect(shortlink.Spec.Code, target)
 	} else {
@@ -99,10 +161,59 @@ func (s *ShortlinkController) HandleShortLink(ct *gin.Context) {
 				"redirectFrom":  ct.Request.URL.Path,
 				"redirectTo":    target,
 				"redirectAfter": shortlink.Spec.RedirectAfter,
+				"description":   shortlink.Spec.Description,
+				"openGraph":     openGraph,
 			},
 		)
 	}
 
+	if isBot {
+		// Crawlers unfurling or scanning a link would inflate the hit counter
+		s.client.IncrementCrawlerCount(ct, shortlink, bot.Name)
+		return
+	}
+
 	// Increase hit counter
-	s.client.IncrementInvocationCount(ct, shortlink)
+	s.client.IncrementInvocationCount(ct, shortlink, invokedAlias(shortlink, shortlinkName))
+}
+
+// getByVariant returns the ShortLink matching a variant of the slug name, e.g. with trailing punctuation removed
+// or underscores instead of dashes. If no variant matches, notFound is returned
+func (s *ShortlinkController) getByVariant(ctx context.Context, name string, notFound error) (*v1alpha1.ShortLink, error) {
+	for _, variant := range slug.Variants(name) {
+		shortlink, err := s.client.GetBySlug(ctx, variant)
+		if err == nil || !k8serrors.IsNotFound(err) {
+			return shortlink, err
+		}
+	}
+
+	return nil, notFound
+}
+
+// suggest returns the slugs of existing ShortLinks which are similar to name
+func (s *ShortlinkController) suggest(ctx context.Context, name string) []string {
+	slugs, err := s.client.ListSlugs(ctx)
+	if err != nil {
+		return nil
+	}
+
+	return slug.Suggest(name, slugs, 3)
+}
+
+// invokedAlias returns the alias of shortlink which was invoked by requesting name,
+// or an empty string if name is the slug of shortlink
+func invokedAlias(shortlink *v1alpha1.ShortLink, name string) string {
+	for _, candidate := range append([]string{name}, slug.Variants(name)...) {
+		if slug.Normalize(candidate) == slug.Normalize(shortlink.GetSlug()) {
+			return ""
+		}
+
+		for _, alias := range shortlink.Spec.Aliases {
+			if slug.Normalize(candidate) == slug.Normalize(alias) {
+				return alias
+			}
+		}
+	}
+
+	return ""
 }
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// uiUserInfo is the response of the user info endpoint of GitHub or an OpenID Connect identity provider
+type uiUserInfo struct {
+	Login             string `json:"login,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+}
+
+// HandleUILogin redirects to the identity provider to log in
+func (u *UIController) HandleUILogin(ct *gin.Context) {
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUILogin")
+		defer span.End()
+	}
+
+	log := otelzap.L().Sugar().With(zap.String("operation", "ui-login"))
+
+	state, err := randomToken()
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to generate OAuth state")
+		ct.HTML(http.StatusInternalServerError, "500.html", gin.H{})
+		return
+	}
+
+	u.setCookie(ct, uiStateCookie, state, 600)
+
+	ct.Redirect(http.StatusFound, u.oauthConfig(ct).AuthCodeURL(state))
+}
+
+// HandleUICallback completes the login after the identity provider redirected back to the UI
+func (u *UIController) HandleUICallback(ct *gin.Context) {
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUICallback")
+		defer span.End()
+	}
+
+	log := otelzap.L().Sugar().With(zap.String("operation", "ui-callback"))
+
+	state, err := ct.Cookie(uiStateCookie)
+	if err != nil || state == "" || state != ct.Query("state") {
+		observability.RecordError(ctx, span, log, fmt.Errorf("OAuth state mismatch"), "Invalid OAuth callback")
+		ct.HTML(http.StatusBadRequest, "ui-error.html", gin.H{
+			"error": "The login expired, please try again",
+		})
+		return
+	}
+
+	u.setCookie(ct, uiStateCookie, "", -1)
+
+	if errorDescription := ct.Query("error_description"); errorDescription != "" {
+		ct.HTML(http.StatusUnauthorized, "ui-error.html", gin.H{
+			"error": errorDescription,
+		})
+		return
+	}
+
+	token, err := u.oauthConfig(ct).Exchange(ctx, ct.Query("code"))
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to exchange OAuth code")
+		ct.HTML(http.StatusUnauthorized, "ui-error.html", gin.H{
+			"error": "Login failed",
+		})
+		return
+	}
+
+	username, err := u.getUsername(ctx, token.AccessToken)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to get user info")
+		ct.HTML(http.StatusUnauthorized, "ui-error.html", gin.H{
+			"error": "Login failed",
+		})
+		return
+	}
+
+	u.setCookie(ct, uiSessionCookie, u.sessions.issue(username), int(u.sessions.duration.Seconds()))
+
+	ct.Redirect(http.StatusSeeOther, "/ui/")
+}
+
+// HandleUILogout ends the session
+func (u *UIController) HandleUILogout(ct *gin.Context) {
+	u.setCookie(ct, uiSessionCookie, "", -1)
+
+	ct.HTML(http.StatusOK, "ui-login.html", gin.H{
+		"message": "You have been logged out",
+	})
+}
+
+// oauthConfig returns the OAuth configuration with the callback URL of the UI
+func (u *UIController) oauthConfig(ct *gin.Context) *oauth2.Config {
+	config := u.oauth
+	config.RedirectURL = u.publicURL + "/ui/callback"
+
+	return &config
+}
+
+// getUsername returns the login of the GitHub user accessToken belongs to. Users of other identity providers are
+// identified by their preferred_username with the prefix of the identity provider
+func (u *UIController) getUsername(ctx context.Context, accessToken string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.userInfoURL, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to build user info request")
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+
+	client := &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to fetch user info")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("user info endpoint responded with %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while reading the response")
+	}
+
+	userInfo := &uiUserInfo{}
+	if err := json.Unmarshal(body, userInfo); err != nil {
+		return "", errors.Wrap(err, "Failed to unmarshal user info")
+	}
+
+	return u.usernameOf(userInfo)
+}
+
+// usernameOf returns the username of the user described by userInfo
+func (u *UIController) usernameOf(userInfo *uiUserInfo) (string, error) {
+	if u.userInfoURL == DefaultUIUserInfoURL {
+		if userInfo.Login == "" {
+			return "", fmt.Errorf("user info contains no login")
+		}
+
+		return userInfo.Login, nil
+	}
+
+	if userInfo.PreferredUsername == "" {
+		return "", fmt.Errorf("user info contains no preferred_username")
+	}
+
+	return u.usernamePrefix + userInfo.PreferredUsername, nil
+}
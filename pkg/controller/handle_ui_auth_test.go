@@ -0,0 +1,44 @@
+package controller
+
+import "testing"
+
+func TestUIConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  UIConfig
+		wantErr bool
+	}{
+		{"github", UIConfig{PublicURL: "https://go.example.com"}, false},
+		{"no public URL", UIConfig{}, true},
+		{"relative public URL", UIConfig{PublicURL: "go.example.com"}, true},
+		{"custom identity provider", UIConfig{PublicURL: "https://go.example.com", UserInfoURL: "https://sso.example.com/userinfo", UsernamePrefix: "sso:"}, false},
+		{"custom identity provider without prefix", UIConfig{PublicURL: "https://go.example.com", UserInfoURL: "https://sso.example.com/userinfo"}, true},
+		{"custom identity provider with login-like prefix", UIConfig{PublicURL: "https://go.example.com", UserInfoURL: "https://sso.example.com/userinfo", UsernamePrefix: "sso-"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUsernameOf(t *testing.T) {
+	userInfo := &uiUserInfo{Login: "octocat", PreferredUsername: "octocat"}
+
+	github := NewUIController(nil, nil, UIConfig{PublicURL: "https://go.example.com"})
+	if username, err := github.usernameOf(userInfo); err != nil || username != "octocat" {
+		t.Errorf("usernameOf() = %q, %v, want octocat", username, err)
+	}
+
+	sso := NewUIController(nil, nil, UIConfig{PublicURL: "https://go.example.com", UserInfoURL: "https://sso.example.com/userinfo", UsernamePrefix: "sso:"})
+	if username, err := sso.usernameOf(userInfo); err != nil || username != "sso:octocat" {
+		t.Errorf("usernameOf() = %q, %v, want sso:octocat", username, err)
+	}
+
+	if _, err := sso.usernameOf(&uiUserInfo{Login: "octocat"}); err == nil {
+		t.Error("usernameOf() accepted the login of another identity provider")
+	}
+}
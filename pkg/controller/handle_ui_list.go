@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"net/http"
+
+	shortlinkClient "github.com/cedi/urlshortener/pkg/client"
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// HandleUILoginPage shows the login page, or the list of shortlinks if the user is logged in already
+func (u *UIController) HandleUILoginPage(ct *gin.Context) {
+	if session, err := ct.Cookie(uiSessionCookie); err == nil {
+		if _, ok := u.sessions.verify(session); ok {
+			ct.Redirect(http.StatusSeeOther, "/ui/")
+			return
+		}
+	}
+
+	ct.HTML(http.StatusOK, "ui-login.html", gin.H{})
+}
+
+// HandleUIList lists the shortlinks of the user, filtered by the search query q
+func (u *UIController) HandleUIList(ct *gin.Context) {
+	username := ct.GetString(uiUsernameKey)
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUIList")
+		defer span.End()
+	}
+
+	span.SetAttributes(
+		attribute.String("username", username),
+		attribute.String("query", ct.Query("q")),
+	)
+
+	log := otelzap.L().Sugar().With(zap.String("operation", "ui-list"),
+		zap.String("username", username),
+	)
+
+	opts := &shortlinkClient.ShortLinkListOptions{
+		Query:          ct.Query("q"),
+		SortBy:         ct.DefaultQuery("sort", shortlinkClient.SortByName),
+		SortDescending: ct.Query("sort") == shortlinkClient.SortByCount || ct.Query("sort") == shortlinkClient.SortByLastModified,
+	}
+
+	shortlinks, err := u.shortlinks.authenticatedClient.List(ctx, username, opts)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to list ShortLinks")
+		u.renderError(ct, statusCodeForError(err), err)
+		return
+	}
+
+	ct.HTML(http.StatusOK, "ui-list.html", u.page(ct, gin.H{
+		"shortlinks": shortlinks.Items,
+		"query":      opts.Query,
+		"sort":       opts.SortBy,
+	}))
+}
+
+// page adds the data shared by all pages of the UI to data
+func (u *UIController) page(ct *gin.Context, data gin.H) gin.H {
+	data["username"] = ct.GetString(uiUsernameKey)
+	data["csrf"] = ct.GetString(uiCSRFField)
+	data["baseURL"] = u.publicURL
+
+	return data
+}
+
+// renderError shows the error page
+func (u *UIController) renderError(ct *gin.Context, statusCode int, err error) {
+	ct.HTML(statusCode, "ui-error.html", u.page(ct, gin.H{
+		"error": err.Error(),
+	}))
+}
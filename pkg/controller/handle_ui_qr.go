@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// HandleUIQRCode renders the QR code of a shortlink as PNG. The image is downloaded if download is set
+func (u *UIController) HandleUIQRCode(ct *gin.Context) {
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUIQRCode")
+		defer span.End()
+	}
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", ct.Param("shortlink")),
+		zap.String("operation", "ui-qr"),
+	)
+
+	shortlink, ok := u.getShortLink(ctx, ct, "ui-qr")
+	if !ok {
+		return
+	}
+
+	scale, err := strconv.Atoi(ct.DefaultQuery("scale", "8"))
+	if err != nil || scale < 1 || scale > 32 {
+		scale = 8
+	}
+
+	code, err := qrcode.New(u.publicURL+"/"+url.PathEscape(shortlink.GetSlug()), qrcode.Medium)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to encode QR code")
+		u.renderError(ct, http.StatusBadRequest, err)
+		return
+	}
+
+	// A negative size renders scale pixels per module
+	image, err := code.PNG(-scale)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to render QR code")
+		u.renderError(ct, http.StatusInternalServerError, err)
+		return
+	}
+
+	if ct.Query("download") != "" {
+		ct.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", shortlink.Name+".png"))
+	}
+
+	ct.Header("Cache-Control", "private, max-age=3600")
+	ct.Data(http.StatusOK, "image/png", image)
+}
@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/model"
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/cedi/urlshortener/pkg/slug"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HandleUINew shows the form for creating a shortlink
+func (u *UIController) HandleUINew(ct *gin.Context) {
+	ct.HTML(http.StatusOK, "ui-edit.html", u.page(ct, gin.H{
+		"new": true,
+		"form": uiForm{
+			Code:  "307",
+			After: "0",
+		},
+	}))
+}
+
+// HandleUICreate creates a shortlink from the submitted form
+func (u *UIController) HandleUICreate(ct *gin.Context) {
+	username := ct.GetString(uiUsernameKey)
+	form := uiFormFromRequest(ct)
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUICreate")
+		defer span.End()
+	}
+
+	span.SetAttributes(
+		attribute.String("shortlink", form.Slug),
+		attribute.String("username", username),
+	)
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", form.Slug),
+		zap.String("operation", "ui-create"),
+	)
+
+	renderForm := func(statusCode int, err error) {
+		ct.HTML(statusCode, "ui-edit.html", u.page(ct, gin.H{
+			"new":   true,
+			"form":  form,
+			"error": err.Error(),
+		}))
+	}
+
+	if form.Slug == "" {
+		renderForm(http.StatusBadRequest, fmt.Errorf("the slug is required"))
+		return
+	}
+
+	shortlink := v1alpha1.ShortLink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: slug.ObjectName(form.Slug),
+		},
+	}
+
+	// The slug is only stored if it isn't a valid object name and is therefore mapped to a different name
+	if shortlink.Name != form.Slug {
+		shortlink.Spec.Slug = form.Slug
+	}
+
+	if err := form.apply(&shortlink.Spec); err != nil {
+		renderForm(http.StatusBadRequest, err)
+		return
+	}
+
+	if statusCode, err := u.shortlinks.validateSlugs(ctx, &shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid slug")
+		renderForm(statusCode, err)
+		return
+	}
+
+	if err := u.shortlinks.authenticatedClient.Create(ctx, username, &shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to create ShortLink")
+		renderForm(statusCodeForError(err), err)
+		return
+	}
+
+	u.shortlinks.recordAudit(ctx, ct, &shortlink, model.AuditOperationCreate, username, nil, &shortlink.Spec)
+
+	ct.Redirect(http.StatusSeeOther, uiShortLinkPath(&shortlink))
+}
+
+// HandleUIEdit shows a shortlink with its statistics and the form for editing it
+func (u *UIController) HandleUIEdit(ct *gin.Context) {
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUIEdit")
+		defer span.End()
+	}
+
+	shortlink, ok := u.getShortLink(ctx, ct, "ui-edit")
+	if !ok {
+		return
+	}
+
+	u.renderShortLink(ct, http.StatusOK, shortlink, newUIForm(shortlink), ct.Query("message"), "")
+}
+
+// HandleUIUpdate updates a shortlink from the submitted form
+func (u *UIController) HandleUIUpdate(ct *gin.Context) {
+	username := ct.GetString(uiUsernameKey)
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUIUpdate")
+		defer span.End()
+	}
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", ct.Param("shortlink")),
+		zap.String("operation", "ui-update"),
+	)
+
+	shortlink, ok := u.getShortLink(ctx, ct, "ui-update")
+	if !ok {
+		return
+	}
+
+	before := shortlink.Spec.DeepCopy()
+	form := uiFormFromRequest(ct)
+	form.Slug = shortlink.GetSlug()
+
+	if err := form.apply(&shortlink.Spec); err != nil {
+		u.renderShortLink(ct, http.StatusBadRequest, shortlink, form, "", err.Error())
+		return
+	}
+
+	if statusCode, err := u.shortlinks.validateSlugs(ctx, shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid slug")
+		u.renderShortLink(ct, statusCode, shortlink, form, "", err.Error())
+		return
+	}
+
+	if err := u.shortlinks.authenticatedClient.Update(ctx, username, shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to update ShortLink")
+		u.renderShortLink(ct, statusCodeForError(err), shortlink, form, "", err.Error())
+		return
+	}
+
+	u.shortlinks.recordAudit(ctx, ct, shortlink, model.AuditOperationUpdate, username, before, &shortlink.Spec)
+
+	ct.Redirect(http.StatusSeeOther, uiShortLinkPath(shortlink)+"?message="+url.QueryEscape("Saved"))
+}
+
+// HandleUIDelete deletes a shortlink
+func (u *UIController) HandleUIDelete(ct *gin.Context) {
+	username := ct.GetString(uiUsernameKey)
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUIDelete")
+		defer span.End()
+	}
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", ct.Param("shortlink")),
+		zap.String("operation", "ui-delete"),
+	)
+
+	shortlink, ok := u.getShortLink(ctx, ct, "ui-delete")
+	if !ok {
+		return
+	}
+
+	if err := u.shortlinks.authenticatedClient.Delete(ctx, username, shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to delete ShortLink")
+		u.renderError(ct, statusCodeForError(err), err)
+		return
+	}
+
+	u.shortlinks.recordAudit(ctx, ct, shortlink, model.AuditOperationDelete, username, &shortlink.Spec, nil)
+
+	ct.Redirect(http.StatusSeeOther, "/ui/")
+}
+
+// HandleUIOwners adds or removes a co-owner of a shortlink. Only the owner can manage the co-owners
+func (u *UIController) HandleUIOwners(ct *gin.Context) {
+	username := ct.GetString(uiUsernameKey)
+
+	ctx := ct.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	// Check if the span was sampled and is recording the data
+	if !span.IsRecording() {
+		ctx, span = u.tracer.Start(ctx, "UIController.HandleUIOwners")
+		defer span.End()
+	}
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", ct.Param("shortlink")),
+		zap.String("operation", "ui-owners"),
+	)
+
+	shortlink, ok := u.getShortLink(ctx, ct, "ui-owners")
+	if !ok {
+		return
+	}
+
+	if shortlink.Spec.Owner != username {
+		u.renderError(ct, http.StatusForbidden, model.NewNotAllowedError(username, "manage the co-owners of", shortlink.Name))
+		return
+	}
+
+	before := shortlink.Spec.DeepCopy()
+	coOwner := strings.TrimSpace(ct.PostForm("coOwner"))
+
+	switch ct.PostForm("action") {
+	case "add":
+		if coOwner != "" && coOwner != shortlink.Spec.Owner && !slices.Contains(shortlink.Spec.CoOwners, coOwner) {
+			shortlink.Spec.CoOwners = append(shortlink.Spec.CoOwners, coOwner)
+		}
+	case "remove":
+		if i := slices.Index(shortlink.Spec.CoOwners, coOwner); i >= 0 {
+			shortlink.Spec.CoOwners = slices.Delete(shortlink.Spec.CoOwners, i, i+1)
+		}
+	default:
+		u.renderError(ct, http.StatusBadRequest, fmt.Errorf("unknown action %q", ct.PostForm("action")))
+		return
+	}
+
+	if err := u.shortlinks.authenticatedClient.Update(ctx, username, shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to update co-owners")
+		u.renderError(ct, statusCodeForError(err), err)
+		return
+	}
+
+	u.shortlinks.recordAudit(ctx, ct, shortlink, model.AuditOperationUpdate, username, before, &shortlink.Spec)
+
+	ct.Redirect(http.StatusSeeOther, uiShortLinkPath(shortlink)+"?message="+url.QueryEscape("Co-owners updated"))
+}
+
+// getShortLink returns the shortlink of the request if the user owns it. Otherwise the error page is rendered
+func (u *UIController) getShortLink(ctx context.Context, ct *gin.Context, operation string) (*v1alpha1.ShortLink, bool) {
+	username := ct.GetString(uiUsernameKey)
+	shortlinkName := ct.Param("shortlink")
+
+	span := trace.SpanFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("shortlink", shortlinkName),
+		attribute.String("username", username),
+	)
+
+	log := otelzap.L().Sugar().With(zap.String("shortlink", shortlinkName),
+		zap.String("operation", operation),
+	)
+
+	shortlink, err := u.shortlinks.authenticatedClient.Get(ctx, username, shortlinkName)
+	if err != nil {
+		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
+		u.renderError(ct, statusCodeForError(err), err)
+		return nil, false
+	}
+
+	return shortlink, true
+}
+
+// renderShortLink shows shortlink with its statistics and form
+func (u *UIController) renderShortLink(ct *gin.Context, statusCode int, shortlink *v1alpha1.ShortLink, form uiForm, message string, err string) {
+	slugStats, crawlerStats := uiStats(shortlink)
+
+	ct.HTML(statusCode, "ui-edit.html", u.page(ct, gin.H{
+		"shortlink":    shortlink,
+		"path":         uiShortLinkPath(shortlink),
+		"url":          u.publicURL + "/" + url.PathEscape(shortlink.GetSlug()),
+		"isOwner":      shortlink.Spec.Owner == ct.GetString(uiUsernameKey),
+		"form":         form,
+		"slugStats":    slugStats,
+		"crawlerStats": crawlerStats,
+		"message":      message,
+		"error":        err,
+	}))
+}
+
+// uiShortLinkPath returns the path of the page of shortlink in the UI
+func uiShortLinkPath(shortlink *v1alpha1.ShortLink) string {
+	return "/ui/links/" + url.PathEscape(shortlink.GetSlug())
+}
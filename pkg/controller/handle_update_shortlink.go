@@ -5,9 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
-	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/model"
 	"github.com/cedi/urlshortener/pkg/observability"
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
@@ -16,38 +15,45 @@ import (
 	"go.uber.org/zap"
 )
 
-// HandleDeleteShortLink handles the update of a shortlink
+// HandleUpdateShortLink handles the update of a shortlink
 // @BasePath /api/v1/
 // @Summary       update existing shortlink
 // @Schemes       http https
-// @Description   update a new shortlink
+// @Description   replace the spec of a shortlink. The owner, co-owners, slug and code are kept if they are omitted, all other omitted fields are reset
 // @Accept        application/json
 // @Produce       text/plain
 // @Produce       application/json
 // @Param         shortlink   path      string                 true   "the shortlink URL part (shortlink id)" example(home)
-// @Param         spec        body      v1alpha1.ShortLinkSpec true   "shortlink spec"
-// @Success       200         {object}  int     "Success"
-// @Failure       401         {object}  int     "Unauthorized"
-// @Failure       404         {object}  int     "NotFound"
-// @Failure       500         {object}  int     "InternalServerError"
+// @Param         spec        body      ShortLinkRequest       true   "shortlink spec and labels"
+// @Param         If-Match    header    string                 false  "only update if the ETag of the shortlink matches"
+// @Success       200         {object}  ShortLink "Success"
+// @Failure       400         {object}  Problem   "BadRequest"
+// @Failure       401         {object}  Problem   "Unauthorized"
+// @Failure       403         {object}  Problem   "Forbidden"
+// @Failure       404         {object}  Problem   "NotFound"
+// @Failure       409         {object}  Problem   "Conflict"
+// @Failure       412         {object}  Problem   "PreconditionFailed"
+// @Failure       429         {object}  Problem   "TooManyRequests"
+// @Failure       500         {object}  Problem   "InternalServerError"
 // @Tags api/v1/
 // @Router /api/v1/shortlink/{shortlink} [put]
 // @Security bearerAuth
 func (s *ShortlinkController) HandleUpdateShortLink(ct *gin.Context) {
 	shortlinkName := ct.Param("shortlink")
-	contentType := ct.Request.Header.Get("accept")
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
 
 	ctx := ct.Request.Context()
 	span := trace.SpanFromContext(ctx)
 
 	// Check if the span was sampled and is recording the data
 	if !span.IsRecording() {
-		ctx, span = s.tracer.Start(ctx, "ShortlinkController.HandleShortLink")
+		ctx, span = s.tracer.Start(ctx, "ShortlinkController.HandleUpdateShortLink")
 		defer span.End()
 	}
 
 	span.SetAttributes(
 		attribute.String("shortlink", shortlinkName),
+		attribute.String("content_type", contentType),
 		attribute.String("referrer", ct.Request.Referer()),
 	)
 
@@ -55,11 +61,8 @@ func (s *ShortlinkController) HandleUpdateShortLink(ct *gin.Context) {
 		zap.String("operation", "update"),
 	)
 
-	bearerToken := ct.Request.Header.Get("Authorization")
-	bearerToken = strings.TrimPrefix(bearerToken, "Bearer")
-	bearerToken = strings.TrimPrefix(bearerToken, "token")
-	if len(bearerToken) == 0 {
-		err := fmt.Errorf("no credentials provided")
+	bearerToken, err := getBearerToken(ct)
+	if err != nil {
 		observability.RecordError(ctx, span, log, err, "no credentials provided")
 		ginReturnError(ct, http.StatusUnauthorized, contentType, err.Error())
 		return
@@ -72,17 +75,15 @@ func (s *ShortlinkController) HandleUpdateShortLink(ct *gin.Context) {
 		return
 	}
 
+	if !s.rateLimiter.LimitUser(ct, githubUser.Login) {
+		return
+	}
+
 	shortlink, err := s.authenticatedClient.Get(ctx, githubUser.Login, shortlinkName)
 	if err != nil {
 		observability.RecordError(ctx, span, log, err, "Failed to get ShortLink")
 
-		statusCode := http.StatusInternalServerError
-
-		if strings.Contains(err.Error(), "not found") {
-			statusCode = http.StatusNotFound
-		}
-
-		ginReturnError(ct, statusCode, contentType, err.Error())
+		ginReturnError(ct, statusCodeForError(err), contentType, err.Error())
 		return
 	}
 
@@ -92,7 +93,15 @@ func (s *ShortlinkController) HandleUpdateShortLink(ct *gin.Context) {
 		return
 	}
 
-	shortlinkSpec := v1alpha1.ShortLinkSpec{}
+	if !ifMatchSatisfied(ct, shortlink) {
+		err := fmt.Errorf("shortlink %s was modified, current ETag is %q", shortlink.Name, shortlink.ResourceVersion)
+		observability.RecordError(ctx, span, log, err, "Precondition failed")
+		ginReturnError(ct, http.StatusPreconditionFailed, contentType, err.Error())
+		return
+	}
+
+	before := shortlink.Spec.DeepCopy()
+	shortlinkRequest := ShortLinkRequest{}
 
 	jsonData, err := io.ReadAll(ct.Request.Body)
 	if err != nil {
@@ -102,21 +111,65 @@ func (s *ShortlinkController) HandleUpdateShortLink(ct *gin.Context) {
 		return
 	}
 
-	if err := json.Unmarshal([]byte(jsonData), &shortlinkSpec); err != nil {
+	if err := json.Unmarshal([]byte(jsonData), &shortlinkRequest); err != nil {
 		observability.RecordError(ctx, span, log, err, "Failed to read ShortLink Spec JSON")
 
-		ginReturnError(ct, http.StatusInternalServerError, contentType, err.Error())
+		ginReturnError(ct, http.StatusBadRequest, contentType, err.Error())
+		return
+	}
+
+	if err := validateLabels(shortlinkRequest.Labels); err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid labels")
+		ginReturnError(ct, http.StatusBadRequest, contentType, err.Error())
 		return
 	}
 
-	shortlink.Spec = shortlinkSpec
+	// The owner can't be wiped by omitting it from the spec
+	if shortlinkRequest.Owner == "" {
+		shortlinkRequest.Owner = shortlink.Spec.Owner
+	}
+
+	// The slug can't be wiped by omitting it from the spec either, as the name may not be a valid slug
+	if shortlinkRequest.Slug == "" {
+		shortlinkRequest.Slug = shortlink.Spec.Slug
+	}
+
+	// Neither can the co-owners, they are only removed by sending an empty list
+	if shortlinkRequest.CoOwners == nil {
+		shortlinkRequest.CoOwners = shortlink.Spec.CoOwners
+	}
+
+	// Nor the code, which would otherwise become invalid
+	if shortlinkRequest.Code == 0 {
+		shortlinkRequest.Code = shortlink.Spec.Code
+	}
+
+	shortlink.Spec = shortlinkRequest.ShortLinkSpec
+
+	// Labels are only replaced if the request contains them
+	if shortlinkRequest.Labels != nil {
+		shortlink.Labels = shortlinkRequest.Labels
+	}
+
+	if statusCode, err := s.validateSlugs(ctx, shortlink); err != nil {
+		observability.RecordError(ctx, span, log, err, "Invalid slug")
+		ginReturnError(ct, statusCode, contentType, err.Error())
+		return
+	}
 
 	if err := s.authenticatedClient.Update(ctx, githubUser.Login, shortlink); err != nil {
 		observability.RecordError(ctx, span, log, err, "Failed to update ShortLink")
 
-		ginReturnError(ct, http.StatusInternalServerError, contentType, err.Error())
+		ginReturnError(ct, statusCodeForWriteError(ct, err), contentType, err.Error())
 		return
 	}
 
-	ginReturnError(ct, http.StatusOK, contentType, "")
+	s.recordAudit(ctx, ct, shortlink, model.AuditOperationUpdate, githubUser.Login, before, &shortlink.Spec)
+
+	setETag(ct, shortlink)
+
+	ginReturn(ct, http.StatusOK, contentType,
+		fmt.Sprintf("%s: %s\n", shortlink.Name, shortlink.Spec.Target),
+		newShortLink(shortlink),
+	)
 }
@@ -6,27 +6,72 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/cedi/urlshortener/pkg/model"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 const (
 	ContentTypeApplicationJSON = "application/json"
 	ContentTypeTextPlain       = "text/plain"
+	ContentTypeProblemJSON     = "application/problem+json"
+	ContentTypeMergePatchJSON  = "application/merge-patch+json"
+	ContentTypeJSONPatchJSON   = "application/json-patch+json"
 )
 
 type ShortLink struct {
 	Name   string                   `json:"name"`
+	Labels map[string]string        `json:"labels,omitempty"`
 	Spec   v1alpha1.ShortLinkSpec   `json:"spec,omitempty"`
 	Status v1alpha1.ShortLinkStatus `json:"status,omitempty"`
 }
 
-type JsonReturnError struct {
-	Code  int    `json:"code"`
-	Error string `json:"error"`
+// ShortLinkRequest is the body of a request creating or updating a shortlink
+type ShortLinkRequest struct {
+	v1alpha1.ShortLinkSpec
+
+	// Labels are set as Kubernetes labels on the shortlink
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// newShortLink converts a v1alpha1.ShortLink into its API representation
+func newShortLink(shortlink *v1alpha1.ShortLink) ShortLink {
+	return ShortLink{
+		Name:   shortlink.Name,
+		Labels: shortlink.Labels,
+		Spec:   shortlink.Spec,
+		Status: shortlink.Status,
+	}
+}
+
+// validateLabels checks that labels are valid Kubernetes labels
+func validateLabels(labels map[string]string) error {
+	return metav1validation.ValidateLabels(labels, field.NewPath("labels")).ToAggregate()
+}
+
+// Problem is an RFC 7807 problem details object, returned by the API for every error
+type Problem struct {
+	// Type is a URI reference identifying the problem type
+	Type string `json:"type"`
+
+	// Title is a short, human-readable summary of the problem type
+	Title string `json:"title"`
+
+	// Status is the HTTP status code of the response
+	Status int `json:"status"`
+
+	// Detail is a human-readable explanation specific to this occurrence of the problem
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is the request path which caused the problem
+	Instance string `json:"instance,omitempty"`
 }
 
 type GithubUser struct {
@@ -38,15 +83,105 @@ type GithubUser struct {
 	Email      string `json:"email,omitempty"`
 }
 
+// ginReturnError responds with an RFC 7807 problem, or with the plain error message if the client asked for text/plain
 func ginReturnError(c *gin.Context, statusCode int, contentType string, err string) {
 	if contentType == ContentTypeTextPlain {
 		c.Data(statusCode, contentType, []byte(err))
-	} else if contentType == ContentTypeApplicationJSON {
-		c.JSON(statusCode, JsonReturnError{
-			Code:  statusCode,
-			Error: err,
-		})
+		return
+	}
+
+	problem, _ := json.Marshal(Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   err,
+		Instance: c.Request.URL.Path,
+	})
+
+	c.Data(statusCode, ContentTypeProblemJSON, problem)
+}
+
+// ginReturn responds with obj as JSON, or with text if the client asked for text/plain
+func ginReturn(c *gin.Context, statusCode int, contentType string, text string, obj any) {
+	if contentType == ContentTypeTextPlain {
+		c.Data(statusCode, contentType, []byte(text))
+		return
 	}
+
+	c.JSON(statusCode, obj)
+}
+
+// statusCodeForError maps errors returned by the ShortlinkClient to the HTTP status code returned by the API
+func statusCodeForError(err error) int {
+	var notAllowedErr *model.NotAllowedError
+	var quotaExceededErr *model.QuotaExceededError
+
+	switch {
+	case errors.As(err, &notAllowedErr), errors.As(err, &quotaExceededErr):
+		return http.StatusForbidden
+	case k8serrors.IsNotFound(err):
+		return http.StatusNotFound
+	case k8serrors.IsAlreadyExists(err), k8serrors.IsConflict(err):
+		return http.StatusConflict
+	case k8serrors.IsInvalid(err), k8serrors.IsBadRequest(err):
+		return http.StatusBadRequest
+	case k8serrors.IsResourceExpired(err), k8serrors.IsGone(err):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// setETag sets the ETag response header to the resourceVersion of the shortlink
+func setETag(c *gin.Context, shortlink *v1alpha1.ShortLink) {
+	c.Header("ETag", fmt.Sprintf("%q", shortlink.ResourceVersion))
+}
+
+// ifMatchSatisfied checks the If-Match request header against the resourceVersion of the shortlink.
+// Requests without If-Match are always satisfied. If-Match uses the strong comparison, so weak ETags never match
+// (RFC 9110, section 13.1.1)
+func ifMatchSatisfied(c *gin.Context, shortlink *v1alpha1.ShortLink) bool {
+	ifMatch := c.Request.Header.Get("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+
+	for _, etag := range strings.Split(ifMatch, ",") {
+		etag = strings.TrimSpace(etag)
+		if strings.HasPrefix(etag, "W/") {
+			continue
+		}
+
+		if etag == "*" || strings.Trim(etag, `"`) == shortlink.ResourceVersion {
+			return true
+		}
+	}
+
+	return false
+}
+
+// statusCodeForWriteError maps errors of a write operation. A conflict caused by a concurrent
+// write is reported as 412 Precondition Failed if the client asked for optimistic concurrency using If-Match
+func statusCodeForWriteError(c *gin.Context, err error) int {
+	if k8serrors.IsConflict(err) && c.Request.Header.Get("If-Match") != "" {
+		return http.StatusPreconditionFailed
+	}
+
+	return statusCodeForError(err)
+}
+
+// getBearerToken extracts the token from the Authorization header
+func getBearerToken(c *gin.Context) (string, error) {
+	bearerToken := c.Request.Header.Get("Authorization")
+	bearerToken = strings.TrimPrefix(bearerToken, "Bearer")
+	bearerToken = strings.TrimPrefix(bearerToken, "token")
+	bearerToken = strings.TrimSpace(bearerToken)
+
+	if len(bearerToken) == 0 {
+		return "", fmt.Errorf("no credentials provided")
+	}
+
+	return bearerToken, nil
 }
 
 func getGitHubUserInfo(c context.Context, bearerToken string) (*GithubUser, error) {
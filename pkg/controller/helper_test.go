@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIfMatchSatisfied(t *testing.T) {
+	shortlink := &v1alpha1.ShortLink{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "42"}}
+
+	tests := []struct {
+		ifMatch string
+		want    bool
+	}{
+		{ifMatch: "", want: true},
+		{ifMatch: "*", want: true},
+		{ifMatch: `"42"`, want: true},
+		{ifMatch: `"41", "42"`, want: true},
+		{ifMatch: `"41"`, want: false},
+		{ifMatch: `W/"42"`, want: false},
+		{ifMatch: `W/"42", "41"`, want: false},
+	}
+
+	for _, test := range tests {
+		ct, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ct.Request = httptest.NewRequest(http.MethodPut, "/api/v1/shortlink/home", nil)
+		ct.Request.Header.Set("If-Match", test.ifMatch)
+
+		if got := ifMatchSatisfied(ct, shortlink); got != test.want {
+			t.Errorf("ifMatchSatisfied(%q) = %t, want %t", test.ifMatch, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cedi/urlshortener/pkg/slug"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// missedSlugs counts the requests for slugs without a shortlink of all ShortlinkControllers
+var missedSlugs = newMissTracker()
+
+func init() {
+	metrics.Registry.MustRegister(missedSlugs)
+}
+
+const (
+	// missedSlugsReported is the number of most missed slugs reported as metric
+	missedSlugsReported = 20
+
+	// missedSlugsTracked bounds the memory used for counting missed slugs
+	missedSlugsTracked = 10000
+)
+
+// missTracker counts requests for slugs without a shortlink and reports the most missed ones as metric,
+// so owners can add aliases for them. The most missed slugs are only determined when the metric is scraped
+type missTracker struct {
+	desc *prometheus.Desc
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newMissTracker() *missTracker {
+	return &missTracker{
+		desc: prometheus.NewDesc(
+			"urlshortener_shortlink_missed",
+			"How often the most requested slugs without a shortlink were requested",
+			[]string{"slug"},
+			nil,
+		),
+		counts: make(map[string]int),
+	}
+}
+
+// record counts a request for the missing name
+func (t *missTracker) record(name string) {
+	name = slug.Normalize(name)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.counts[name]; !ok && len(t.counts) >= missedSlugsTracked {
+		t.evict()
+	}
+
+	t.counts[name]++
+}
+
+// evict makes room for new slugs, as random probes of bots would grow the map forever. The slugs missed only
+// once are dropped and the counts of the others are halved, so that slugs which are no longer requested fade out.
+// As many slugs are evicted at once, this doesn't happen on every request
+func (t *missTracker) evict() {
+	for len(t.counts) >= missedSlugsTracked {
+		for name, count := range t.counts {
+			if count <= 1 {
+				delete(t.counts, name)
+			} else {
+				t.counts[name] = count / 2
+			}
+		}
+	}
+}
+
+// Describe implements prometheus.Collector
+func (t *missTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.desc
+}
+
+// Collect implements prometheus.Collector and reports the most missed slugs
+func (t *missTracker) Collect(ch chan<- prometheus.Metric) {
+	for _, missed := range t.top(missedSlugsReported) {
+		ch <- prometheus.MustNewConstMetric(t.desc, prometheus.GaugeValue, float64(missed.count), missed.name)
+	}
+}
+
+type missedSlug struct {
+	name  string
+	count int
+}
+
+// top returns the n most missed slugs, most missed first
+func (t *missTracker) top(n int) []missedSlug {
+	t.mu.Lock()
+	missed := make([]missedSlug, 0, len(t.counts))
+	for name, count := range t.counts {
+		missed = append(missed, missedSlug{name: name, count: count})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(missed, func(i, j int) bool {
+		if missed[i].count != missed[j].count {
+			return missed[i].count > missed[j].count
+		}
+
+		return missed[i].name < missed[j].name
+	})
+
+	if len(missed) > n {
+		missed = missed[:n]
+	}
+
+	return missed
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMissTrackerTop(t *testing.T) {
+	tracker := newMissTracker()
+
+	for i := 0; i < 3; i++ {
+		tracker.record("Popular")
+	}
+	tracker.record("rare")
+	tracker.record("also-rare")
+
+	top := tracker.top(2)
+	if len(top) != 2 || top[0] != (missedSlug{name: "popular", count: 3}) || top[1] != (missedSlug{name: "also-rare", count: 1}) {
+		t.Errorf("top(2) = %v, want popular with 3 and also-rare with 1", top)
+	}
+}
+
+func TestMissTrackerEvictsProbes(t *testing.T) {
+	tracker := newMissTracker()
+
+	for i := 0; i < 10; i++ {
+		tracker.record("popular")
+	}
+
+	for i := 0; i < 3*missedSlugsTracked; i++ {
+		tracker.record(fmt.Sprintf("probe-%d", i))
+	}
+
+	if len(tracker.counts) > missedSlugsTracked {
+		t.Errorf("tracking %d slugs, want at most %d", len(tracker.counts), missedSlugsTracked)
+	}
+
+	if top := tracker.top(1); len(top) != 1 || top[0].name != "popular" {
+		t.Errorf("top(1) = %v, want popular", top)
+	}
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/url"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// addQueryParameters adds the default query parameters of the namespace and the query parameters of shortlink
+// to target. The parameters of shortlink take precedence over the defaults, whether parameters already in target
+// are replaced depends on the QueryParameterPolicy of shortlink
+func addQueryParameters(target string, shortlink *v1alpha1.ShortLink, defaults map[string]string) (string, error) {
+	if len(defaults) == 0 && len(shortlink.Spec.QueryParameters) == 0 {
+		return target, nil
+	}
+
+	parameters := make(map[string]string, len(defaults)+len(shortlink.Spec.QueryParameters))
+	for name, value := range defaults {
+		parameters[name] = value
+	}
+
+	for name, value := range shortlink.Spec.QueryParameters {
+		parameters[name] = value
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to parse target")
+	}
+
+	query := u.Query()
+	changed := false
+
+	for name, value := range parameters {
+		if query.Has(name) && shortlink.Spec.QueryParameterPolicy != v1alpha1.QueryParameterPolicyOverride {
+			continue
+		}
+
+		query.Set(name, value)
+		changed = true
+	}
+
+	// Only re-encode the query if needed, so the target is otherwise passed on as is
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String(), nil
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/cedi/urlshortener/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var rateLimited = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "urlshortener_rate_limited_total",
+		Help: "Number of requests rejected by the rate limits",
+	},
+	[]string{
+		"limit",
+	},
+)
+
+func init() {
+	metrics.Registry.MustRegister(rateLimited)
+}
+
+// RateLimits configures the token bucket rate limits of the routes in requests per second. 0 disables a limit
+type RateLimits struct {
+	// RedirectPerIP limits the requests of shortlinks per client IP, which slows down enumerating slugs
+	RedirectPerIP float64
+
+	// APIPerIP limits the requests of the API per client IP
+	APIPerIP float64
+
+	// APIPerUser limits the requests of the API and the UI per authenticated user
+	APIPerUser float64
+
+	// Burst is the number of requests allowed at once before a limit applies
+	Burst int
+}
+
+// RateLimiter rejects requests exceeding the RateLimits with 429 Too Many Requests
+type RateLimiter struct {
+	redirectPerIP *ratelimit.Limiter
+	apiPerIP      *ratelimit.Limiter
+	apiPerUser    *ratelimit.Limiter
+}
+
+// NewRateLimiter creates a new RateLimiter enforcing limits
+func NewRateLimiter(limits RateLimits) *RateLimiter {
+	return &RateLimiter{
+		redirectPerIP: ratelimit.New(limits.RedirectPerIP, limits.Burst),
+		apiPerIP:      ratelimit.New(limits.APIPerIP, limits.Burst),
+		apiPerUser:    ratelimit.New(limits.APIPerUser, limits.Burst),
+	}
+}
+
+// LimitRedirect is a middleware limiting the requests of shortlinks per client IP
+func (l *RateLimiter) LimitRedirect(ct *gin.Context) {
+	if allowed, retryAfter := l.redirectPerIP.Allow(ct.ClientIP()); !allowed {
+		rateLimited.WithLabelValues("redirect_ip").Inc()
+		setRetryAfter(ct, retryAfter)
+		ct.AbortWithStatus(http.StatusTooManyRequests)
+	}
+}
+
+// LimitAPI is a middleware limiting the requests of the API and the UI per client IP.
+// It applies before the authentication, which therefore isn't attempted more often than the limit allows
+func (l *RateLimiter) LimitAPI(ct *gin.Context) {
+	contentType := negotiateContentType(ct.Request.Header.Get("accept"))
+
+	if allowed, retryAfter := l.apiPerIP.Allow(ct.ClientIP()); !allowed {
+		rateLimited.WithLabelValues("api_ip").Inc()
+		setRetryAfter(ct, retryAfter)
+		ginReturnError(ct, http.StatusTooManyRequests, contentType, fmt.Sprintf("too many requests from %s", ct.ClientIP()))
+		ct.Abort()
+	}
+}
+
+// LimitUser limits the requests of the authenticated user username. If the limit is exceeded, the request is
+// rejected and false is returned. As the limit applies after the authentication, it can't be dodged by sending
+// a different token with every request
+func (l *RateLimiter) LimitUser(ct *gin.Context, username string) bool {
+	allowed, retryAfter := l.apiPerUser.Allow(username)
+	if !allowed {
+		rateLimited.WithLabelValues("api_user").Inc()
+		setRetryAfter(ct, retryAfter)
+		ginReturnError(ct, http.StatusTooManyRequests, negotiateContentType(ct.Request.Header.Get("accept")), "too many requests for this user")
+		ct.Abort()
+	}
+
+	return allowed
+}
+
+// LimitUIUser is a middleware limiting the requests of the user logged in to the UI. It must follow RequireLogin
+func (l *RateLimiter) LimitUIUser(ct *gin.Context) {
+	l.LimitUser(ct, ct.GetString(uiUsernameKey))
+}
+
+// setRetryAfter sets the Retry-After header to retryAfter, rounded up to full seconds
+func setRetryAfter(ct *gin.Context, retryAfter time.Duration) {
+	ct.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(retryAfter.Seconds()))))
+}
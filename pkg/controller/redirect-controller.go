@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"strings"
+
+	shortlinkClient "github.com/cedi/urlshortener/pkg/client"
+	redirectpkg "github.com/cedi/urlshortener/pkg/redirect"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedirectController is an object who handles the requests made towards native redirects
+type RedirectController struct {
+	client      *shortlinkClient.RedirectClient
+	tracer      trace.Tracer
+	rateLimiter *RateLimiter
+	resolver    *redirectpkg.Resolver
+
+	// namespace is the namespace of the urlshortener. Only native Redirects in it are served
+	namespace string
+
+	// ownHosts are the hosts the urlshortener itself is served at, which native Redirects can't take over
+	ownHosts []string
+}
+
+// NewRedirectController creates a new RedirectController serving the native Redirects in namespace.
+// Requests to ownHosts are never looked up, they are always passed on to the routes of the urlshortener
+func NewRedirectController(tracer trace.Tracer, client *shortlinkClient.RedirectClient, rateLimiter *RateLimiter, namespace string, ownHosts []string) *RedirectController {
+	hosts := make([]string, 0, len(ownHosts))
+	for _, host := range ownHosts {
+		hosts = append(hosts, strings.ToLower(host))
+	}
+
+	controller := &RedirectController{
+		tracer:      tracer,
+		client:      client,
+		rateLimiter: rateLimiter,
+		resolver:    redirectpkg.NewResolver(),
+		namespace:   namespace,
+		ownHosts:    hosts,
+	}
+
+	return controller
+}
@@ -1,25 +1,116 @@
 package controller
 
 import (
-	shortlinkClient "github.com/cedi/urlshortener/pkg/client"
+	"context"
+	"fmt"
+	"net/http"
 
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	shortlinkClient "github.com/cedi/urlshortener/pkg/client"
+	"github.com/cedi/urlshortener/pkg/model"
+	"github.com/cedi/urlshortener/pkg/observability"
+	"github.com/cedi/urlshortener/pkg/slug"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 // ShortlinkController is an object who handles the requests made towards our shortlink-application
 type ShortlinkController struct {
 	client              *shortlinkClient.ShortlinkClient
 	authenticatedClient *shortlinkClient.ShortlinkClientAuth
+	auditClient         *shortlinkClient.AuditClient
+	queryDefaultsClient *shortlinkClient.QueryDefaultsClient
 	tracer              trace.Tracer
+
+	// rateLimiter limits the requests of the authenticated users
+	rateLimiter *RateLimiter
+
+	// reservedSlugs can't be used by ShortLinks, as they collide with the routes of the urlshortener
+	reservedSlugs []string
+
+	// missed counts requests for slugs without a shortlink
+	missed *missTracker
+
+	// crawlerPreview serves link preview crawlers a preview page instead of redirecting them
+	crawlerPreview bool
 }
 
 // NewShortlinkController creates a new ShortlinkController
-func NewShortlinkController(tracer trace.Tracer, client *shortlinkClient.ShortlinkClient) *ShortlinkController {
+func NewShortlinkController(tracer trace.Tracer, client *shortlinkClient.ShortlinkClient, auditClient *shortlinkClient.AuditClient, queryDefaultsClient *shortlinkClient.QueryDefaultsClient, revisionHistoryLimit int, maxShortlinksPerUser int, rateLimiter *RateLimiter, reservedSlugs []string, crawlerPreview bool) *ShortlinkController {
 	controller := &ShortlinkController{
 		tracer:              tracer,
 		client:              client,
-		authenticatedClient: shortlinkClient.NewAuthenticatedShortlinkClient(tracer, client),
+		authenticatedClient: shortlinkClient.NewAuthenticatedShortlinkClient(tracer, client, revisionHistoryLimit, maxShortlinksPerUser),
+		auditClient:         auditClient,
+		queryDefaultsClient: queryDefaultsClient,
+		rateLimiter:         rateLimiter,
+		reservedSlugs:       reservedSlugs,
+		missed:              missedSlugs,
+		crawlerPreview:      crawlerPreview,
 	}
 
 	return controller
 }
+
+// recordAudit adds an entry to the audit trail of shortlink. The operation already succeeded at this point,
+// so a failure to record it is logged but doesn't fail the request
+func (s *ShortlinkController) recordAudit(ctx context.Context, ct *gin.Context, shortlink *v1alpha1.ShortLink, operation string, actor string, before *v1alpha1.ShortLinkSpec, after *v1alpha1.ShortLinkSpec) {
+	entry := model.NewAuditEntry(operation, actor, ct.ClientIP(), before, after)
+
+	if err := s.auditClient.Record(ctx, shortlink, entry); err != nil {
+		log := otelzap.L().Sugar().With(zap.String("shortlink", shortlink.Name),
+			zap.String("operation", operation),
+		)
+		observability.RecordError(ctx, trace.SpanFromContext(ctx), log, err, "Failed to record audit entry")
+	}
+}
+
+// validateSlugs checks that the slug and aliases of shortlink are valid, not reserved and not used by another
+// ShortLink, and claims them for shortlink. The HTTP status code for the error is returned alongside it
+func (s *ShortlinkController) validateSlugs(ctx context.Context, shortlink *v1alpha1.ShortLink) (int, error) {
+	seen := make(map[string]bool)
+
+	for _, name := range shortlink.GetSlugs() {
+		if err := slug.Validate(name); err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		if slug.IsReserved(name, s.reservedSlugs) {
+			return http.StatusBadRequest, fmt.Errorf("slug %q is reserved", name)
+		}
+
+		if seen[slug.Normalize(name)] {
+			return http.StatusBadRequest, fmt.Errorf("slug %q is used more than once", name)
+		}
+		seen[slug.Normalize(name)] = true
+
+		existing, err := s.client.GetBySlug(ctx, name)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+
+			return http.StatusInternalServerError, err
+		}
+
+		if existing.Name != shortlink.Name {
+			return http.StatusConflict, &shortlinkClient.SlugConflictError{Slug: name, ShortLink: existing.Name}
+		}
+	}
+
+	// The check above is served from the cache and can't see concurrent requests, the claims can
+	if err := s.client.ClaimSlugs(ctx, shortlink); err != nil {
+		var conflict *shortlinkClient.SlugConflictError
+		if errors.As(err, &conflict) {
+			return http.StatusConflict, err
+		}
+
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// DefaultUIUserInfoURL is the GitHub endpoint returning the login of the user the OAuth token belongs to
+const DefaultUIUserInfoURL = "https://api.github.com/user"
+
+// UIConfig configures the OAuth login of the web UI
+type UIConfig struct {
+	// ClientID and ClientSecret identify the urlshortener at the identity provider
+	ClientID     string
+	ClientSecret string
+
+	// AuthURL and TokenURL are the OAuth endpoints of the identity provider. Default to GitHub
+	AuthURL  string
+	TokenURL string
+
+	// UserInfoURL returns the login (GitHub) or preferred_username (OpenID Connect) of the user. Defaults to GitHub
+	UserInfoURL string
+
+	// UsernamePrefix is prepended to the preferred_username of the users of an identity provider other than GitHub,
+	// so that they can't act as the GitHub user of the same name. Required if UserInfoURL isn't GitHub
+	UsernamePrefix string
+
+	// Scopes are requested from the identity provider
+	Scopes []string
+
+	// PublicURL is the URL the urlshortener is served at, e.g. https://go.example.com.
+	// It is required, as the Host and X-Forwarded-Proto headers of a request can be forged
+	PublicURL string
+
+	// SessionSecret signs the session cookies. All replicas must use the same secret
+	SessionSecret []byte
+
+	// SessionDuration is how long a login is valid
+	SessionDuration time.Duration
+}
+
+// usernamePrefixPattern matches prefixes which contain a character that GitHub logins can't contain
+var usernamePrefixPattern = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+
+// Validate returns an error if the web UI can't be served securely with config
+func (config UIConfig) Validate() error {
+	u, err := url.Parse(config.PublicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("the public URL %q must be an absolute http or https URL", config.PublicURL)
+	}
+
+	if config.UserInfoURL != "" && config.UserInfoURL != DefaultUIUserInfoURL {
+		if !usernamePrefixPattern.MatchString(config.UsernamePrefix) {
+			return fmt.Errorf("the username prefix %q of an identity provider other than GitHub must contain a character other than letters, digits and hyphens, e.g. sso:", config.UsernamePrefix)
+		}
+	}
+
+	return nil
+}
+
+// UIController serves the web UI for managing shortlinks under /ui
+type UIController struct {
+	shortlinks  *ShortlinkController
+	tracer      trace.Tracer
+	oauth       oauth2.Config
+	userInfoURL string
+	// usernamePrefix is prepended to the usernames of identity providers other than GitHub
+	usernamePrefix string
+	// publicURL is used instead of the Host and X-Forwarded-Proto headers of the request, which can be forged
+	publicURL string
+	sessions  *sessionSigner
+}
+
+// NewUIController creates a new UIController, which manages shortlinks using shortlinkController.
+// config must be valid
+func NewUIController(tracer trace.Tracer, shortlinkController *ShortlinkController, config UIConfig) *UIController {
+	endpoint := github.Endpoint
+	if config.AuthURL != "" {
+		endpoint.AuthURL = config.AuthURL
+	}
+
+	if config.TokenURL != "" {
+		endpoint.TokenURL = config.TokenURL
+	}
+
+	userInfoURL := config.UserInfoURL
+	if userInfoURL == "" {
+		userInfoURL = DefaultUIUserInfoURL
+	}
+
+	return &UIController{
+		shortlinks: shortlinkController,
+		tracer:     tracer,
+		oauth: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			Endpoint:     endpoint,
+			Scopes:       config.Scopes,
+		},
+		userInfoURL:    userInfoURL,
+		usernamePrefix: config.UsernamePrefix,
+		publicURL:      strings.TrimSuffix(config.PublicURL, "/"),
+		sessions:       newSessionSigner(config.SessionSecret, config.SessionDuration),
+	}
+}
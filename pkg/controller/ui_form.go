@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// uiDateTimeFormat is the format of datetime-local inputs. Times are entered in UTC
+const uiDateTimeFormat = "2006-01-02T15:04"
+
+// uiForm holds the fields of the shortlink form of the UI as entered by the user
+type uiForm struct {
+	Slug                 string
+	Target               string
+	Code                 string
+	After                string
+	Description          string
+	Tags                 string
+	Aliases              string
+	ExpiresAt            string
+	DisableHealthCheck   bool
+	QueryParameters      string
+	QueryParameterPolicy string
+	OpenGraphTitle       string
+	OpenGraphDescription string
+	OpenGraphImage       string
+	OpenGraphFetch       bool
+}
+
+// newUIForm returns the form for editing shortlink
+func newUIForm(shortlink *v1alpha1.ShortLink) uiForm {
+	form := uiForm{
+		Slug:                 shortlink.GetSlug(),
+		Target:               shortlink.Spec.Target,
+		Code:                 strconv.Itoa(shortlink.Spec.Code),
+		After:                strconv.FormatInt(shortlink.Spec.RedirectAfter, 10),
+		Description:          shortlink.Spec.Description,
+		Tags:                 strings.Join(shortlink.Spec.Tags, ", "),
+		Aliases:              strings.Join(shortlink.Spec.Aliases, ", "),
+		DisableHealthCheck:   shortlink.Spec.DisableHealthCheck,
+		QueryParameterPolicy: shortlink.Spec.QueryParameterPolicy,
+	}
+
+	if shortlink.Spec.ExpiresAt != nil {
+		form.ExpiresAt = shortlink.Spec.ExpiresAt.UTC().Format(uiDateTimeFormat)
+	}
+
+	names := make([]string, 0, len(shortlink.Spec.QueryParameters))
+	for name := range shortlink.Spec.QueryParameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parameters := make([]string, 0, len(names))
+	for _, name := range names {
+		parameters = append(parameters, name+"="+shortlink.Spec.QueryParameters[name])
+	}
+	form.QueryParameters = strings.Join(parameters, "\n")
+
+	if openGraph := shortlink.Spec.OpenGraph; openGraph != nil {
+		form.OpenGraphTitle = openGraph.Title
+		form.OpenGraphDescription = openGraph.Description
+		form.OpenGraphImage = openGraph.Image
+		form.OpenGraphFetch = openGraph.Fetch
+	}
+
+	return form
+}
+
+// uiFormFromRequest reads the form submitted with the request
+func uiFormFromRequest(ct *gin.Context) uiForm {
+	return uiForm{
+		Slug:                 strings.TrimSpace(ct.PostForm("slug")),
+		Target:               strings.TrimSpace(ct.PostForm("target")),
+		Code:                 ct.PostForm("code"),
+		After:                ct.PostForm("after"),
+		Description:          strings.TrimSpace(ct.PostForm("description")),
+		Tags:                 ct.PostForm("tags"),
+		Aliases:              ct.PostForm("aliases"),
+		ExpiresAt:            ct.PostForm("expiresAt"),
+		DisableHealthCheck:   ct.PostForm("disableHealthCheck") != "",
+		QueryParameters:      ct.PostForm("queryParameters"),
+		QueryParameterPolicy: ct.PostForm("queryParameterPolicy"),
+		OpenGraphTitle:       strings.TrimSpace(ct.PostForm("ogTitle")),
+		OpenGraphDescription: strings.TrimSpace(ct.PostForm("ogDescription")),
+		OpenGraphImage:       strings.TrimSpace(ct.PostForm("ogImage")),
+		OpenGraphFetch:       ct.PostForm("ogFetch") != "",
+	}
+}
+
+// apply sets the fields of spec from the form. The owners and the slug are left untouched
+func (f uiForm) apply(spec *v1alpha1.ShortLinkSpec) error {
+	if f.Target == "" {
+		return fmt.Errorf("the target is required")
+	}
+
+	code, err := strconv.Atoi(f.Code)
+	if err != nil {
+		return fmt.Errorf("invalid code %q", f.Code)
+	}
+
+	after := int64(0)
+	if f.After != "" {
+		if after, err = strconv.ParseInt(f.After, 10, 64); err != nil || after < 0 || after > 99 {
+			return fmt.Errorf("the redirect delay must be between 0 and 99 seconds")
+		}
+	}
+
+	var expiresAt *metav1.Time
+	if f.ExpiresAt != "" {
+		expires, err := time.Parse(uiDateTimeFormat, f.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("invalid expiry date %q", f.ExpiresAt)
+		}
+
+		expiresAt = &metav1.Time{Time: expires}
+	}
+
+	var queryParameters map[string]string
+	for _, line := range strings.Split(f.QueryParameters, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if !found || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid query parameter %q, expected name=value", line)
+		}
+
+		if queryParameters == nil {
+			queryParameters = make(map[string]string)
+		}
+		queryParameters[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	var openGraph *v1alpha1.OpenGraphSpec
+	if f.OpenGraphTitle != "" || f.OpenGraphDescription != "" || f.OpenGraphImage != "" || f.OpenGraphFetch {
+		openGraph = &v1alpha1.OpenGraphSpec{
+			Title:       f.OpenGraphTitle,
+			Description: f.OpenGraphDescription,
+			Image:       f.OpenGraphImage,
+			Fetch:       f.OpenGraphFetch,
+		}
+	}
+
+	spec.Target = f.Target
+	spec.Code = code
+	spec.RedirectAfter = after
+	spec.Description = f.Description
+	spec.Tags = splitList(f.Tags)
+	spec.Aliases = splitList(f.Aliases)
+	spec.ExpiresAt = expiresAt
+	spec.DisableHealthCheck = f.DisableHealthCheck
+	spec.QueryParameters = queryParameters
+	spec.QueryParameterPolicy = f.QueryParameterPolicy
+	spec.OpenGraph = openGraph
+
+	return nil
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(list string) []string {
+	var entries []string
+
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// uiStat is a bar of a chart in the UI
+type uiStat struct {
+	Label   string
+	Count   int
+	Percent int
+}
+
+// uiStats returns the invocations of shortlink per slug and the requests per crawler as bars
+// relative to the largest count of each chart
+func uiStats(shortlink *v1alpha1.ShortLink) ([]uiStat, []uiStat) {
+	primary := shortlink.Status.Count
+	for _, count := range shortlink.Status.AliasCounts {
+		primary -= count
+	}
+
+	slugs := []uiStat{{Label: shortlink.GetSlug(), Count: primary}}
+	for _, alias := range shortlink.Spec.Aliases {
+		slugs = append(slugs, uiStat{Label: alias, Count: shortlink.Status.AliasCounts[alias]})
+	}
+
+	// Aliases which were removed keep their count
+	removed := make([]string, 0)
+	for alias := range shortlink.Status.AliasCounts {
+		if !slices.Contains(shortlink.Spec.Aliases, alias) {
+			removed = append(removed, alias)
+		}
+	}
+	sort.Strings(removed)
+
+	for _, alias := range removed {
+		slugs = append(slugs, uiStat{Label: alias + " (removed)", Count: shortlink.Status.AliasCounts[alias]})
+	}
+
+	crawlers := make([]uiStat, 0, len(shortlink.Status.CrawlerCounts))
+	for crawler, count := range shortlink.Status.CrawlerCounts {
+		crawlers = append(crawlers, uiStat{Label: crawler, Count: count})
+	}
+
+	sort.Slice(crawlers, func(i, j int) bool {
+		return crawlers[i].Count > crawlers[j].Count || crawlers[i].Count == crawlers[j].Count && crawlers[i].Label < crawlers[j].Label
+	})
+
+	return scaleStats(slugs), scaleStats(crawlers)
+}
+
+func scaleStats(stats []uiStat) []uiStat {
+	largest := 0
+	for _, stat := range stats {
+		if stat.Count > largest {
+			largest = stat.Count
+		}
+	}
+
+	for i := range stats {
+		if largest > 0 {
+			stats[i].Percent = stats[i].Count * 100 / largest
+		}
+	}
+
+	return stats
+}
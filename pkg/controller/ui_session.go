@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// uiSessionCookie holds the signed login of the user
+	uiSessionCookie = "urlshortener_session"
+
+	// uiStateCookie holds the OAuth state during the login, protecting the callback against CSRF
+	uiStateCookie = "urlshortener_oauth_state"
+
+	// uiUsernameKey is the key of the logged in user in the gin context
+	uiUsernameKey = "ui_username"
+
+	// uiCSRFField is the name of the form field holding the CSRF token
+	uiCSRFField = "csrf"
+)
+
+// sessionSigner issues and verifies session cookies of the form base64(username|expiry).signature, so that no
+// session state has to be stored server-side
+type sessionSigner struct {
+	secret   []byte
+	duration time.Duration
+}
+
+// newSessionSigner creates a new sessionSigner. Without secret, a random secret is used, which invalidates all
+// sessions on restart and doesn't work with more than one replica
+func newSessionSigner(secret []byte, duration time.Duration) *sessionSigner {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(fmt.Sprintf("failed to generate session secret: %v", err))
+		}
+	}
+
+	if duration == 0 {
+		duration = 12 * time.Hour
+	}
+
+	return &sessionSigner{
+		secret:   secret,
+		duration: duration,
+	}
+}
+
+// issue returns a session cookie value for username
+func (s *sessionSigner) issue(username string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s|%d", username, time.Now().Add(s.duration).Unix())))
+	return payload + "." + s.sign(payload)
+}
+
+// verify returns the username of a session cookie value, or false if it is invalid or expired
+func (s *sessionSigner) verify(value string) (string, bool) {
+	payload, signature, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return "", false
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", false
+	}
+
+	// The username could contain the separator, the expiry can't
+	separator := strings.LastIndex(string(decoded), "|")
+	if separator < 0 {
+		return "", false
+	}
+
+	username := string(decoded[:separator])
+	expires, err := strconv.ParseInt(string(decoded[separator+1:]), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", false
+	}
+
+	return username, true
+}
+
+// csrfToken returns the CSRF token of forms in the session with the cookie value session
+func (s *sessionSigner) csrfToken(session string) string {
+	return s.sign("csrf|" + session)
+}
+
+func (s *sessionSigner) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RequireLogin is a middleware redirecting users without a valid session to the login. For POST requests,
+// the CSRF token of the form is checked as well
+func (u *UIController) RequireLogin(ct *gin.Context) {
+	session, err := ct.Cookie(uiSessionCookie)
+	if err != nil {
+		ct.Redirect(http.StatusSeeOther, "/ui/login")
+		ct.Abort()
+		return
+	}
+
+	username, ok := u.sessions.verify(session)
+	if !ok {
+		ct.Redirect(http.StatusSeeOther, "/ui/login")
+		ct.Abort()
+		return
+	}
+
+	if ct.Request.Method == http.MethodPost && !hmac.Equal([]byte(ct.PostForm(uiCSRFField)), []byte(u.sessions.csrfToken(session))) {
+		ct.HTML(http.StatusForbidden, "ui-error.html", gin.H{
+			"username": username,
+			"error":    "The form expired, please reload the page and try again",
+		})
+		ct.Abort()
+		return
+	}
+
+	ct.Set(uiUsernameKey, username)
+	ct.Set(uiCSRFField, u.sessions.csrfToken(session))
+}
+
+// setCookie sets a cookie restricted to the UI, which is only sent over HTTPS if the public URL is HTTPS
+func (u *UIController) setCookie(ct *gin.Context, name string, value string, maxAge int) {
+	secure := strings.HasPrefix(u.publicURL, "https://")
+
+	ct.SetSameSite(http.SameSiteLaxMode)
+	ct.SetCookie(name, value, maxAge, "/ui", "", secure, true)
+}
+
+// randomToken returns a random URL safe token
+func randomToken() (string, error) {
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
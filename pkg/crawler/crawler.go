@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Crawler describes a client which requests shortlinks automatically rather than on behalf of a human
+type Crawler struct {
+	// Name identifies the crawler, e.g. slack or teams
+	Name string
+
+	// Preview is true for crawlers rendering a preview of the link in a chat or social network
+	Preview bool
+}
+
+// signature identifies a crawler by a substring of its lower case user agent
+type signature struct {
+	substring string
+	crawler   Crawler
+}
+
+// signatures of known crawlers. The first matching signature applies, so specific signatures must come first.
+// Command line tools and HTTP libraries aren't crawlers, as they are mostly used by humans following a link
+var signatures = []signature{
+	{"slackbot", Crawler{Name: "slack", Preview: true}},
+	{"slack-imgproxy", Crawler{Name: "slack", Preview: true}},
+	{"skypeuripreview", Crawler{Name: "teams", Preview: true}},
+	{"microsoftpreview", Crawler{Name: "teams", Preview: true}},
+	{"discordbot", Crawler{Name: "discord", Preview: true}},
+	{"mattermost-bot", Crawler{Name: "mattermost", Preview: true}},
+	{"rocket.chat", Crawler{Name: "rocketchat", Preview: true}},
+	{"telegrambot", Crawler{Name: "telegram", Preview: true}},
+	{"whatsapp", Crawler{Name: "whatsapp", Preview: true}},
+	{"facebookexternalhit", Crawler{Name: "facebook", Preview: true}},
+	{"facebookcatalog", Crawler{Name: "facebook", Preview: true}},
+	{"twitterbot", Crawler{Name: "twitter", Preview: true}},
+	{"linkedinbot", Crawler{Name: "linkedin", Preview: true}},
+	{"pinterest", Crawler{Name: "pinterest", Preview: true}},
+	{"redditbot", Crawler{Name: "reddit", Preview: true}},
+	{"mastodon", Crawler{Name: "mastodon", Preview: true}},
+	{"embedly", Crawler{Name: "embedly", Preview: true}},
+	{"iframely", Crawler{Name: "iframely", Preview: true}},
+	{"googlebot", Crawler{Name: "google"}},
+	{"google-inspectiontool", Crawler{Name: "google"}},
+	{"bingbot", Crawler{Name: "bing"}},
+	{"bingpreview", Crawler{Name: "bing"}},
+	{"duckduckbot", Crawler{Name: "duckduckgo"}},
+	{"yandex", Crawler{Name: "yandex"}},
+	{"baiduspider", Crawler{Name: "baidu"}},
+	{"applebot", Crawler{Name: "apple"}},
+	{"urlscan", Crawler{Name: "scanner"}},
+	{"virustotal", Crawler{Name: "scanner"}},
+	{"proofpoint", Crawler{Name: "scanner"}},
+	{"mimecast", Crawler{Name: "scanner"}},
+	{"barracuda", Crawler{Name: "scanner"}},
+	{"safelinks", Crawler{Name: "scanner"}},
+	{"headlesschrome", Crawler{Name: "headless"}},
+	{"phantomjs", Crawler{Name: "headless"}},
+	{"bot", Crawler{Name: "bot"}},
+	{"crawler", Crawler{Name: "bot"}},
+	{"spider", Crawler{Name: "bot"}},
+	{"scanner", Crawler{Name: "scanner"}},
+	{"preview", Crawler{Name: "bot", Preview: true}},
+}
+
+// Detect classifies the client of req by its user agent and headers. It returns false if the request
+// looks like it was made by a human using a browser
+func Detect(req *http.Request) (Crawler, bool) {
+	userAgent := strings.ToLower(strings.TrimSpace(req.UserAgent()))
+	if userAgent == "" {
+		return Crawler{Name: "unknown"}, true
+	}
+
+	for _, sig := range signatures {
+		if strings.Contains(userAgent, sig.substring) {
+			return sig.crawler, true
+		}
+	}
+
+	// Browsers prefetching a link announce it, the user may never open the page
+	purpose := strings.ToLower(req.Header.Get("Sec-Purpose") + req.Header.Get("Purpose") + req.Header.Get("X-Purpose"))
+	if strings.Contains(purpose, "prefetch") || strings.Contains(purpose, "preview") {
+		return Crawler{Name: "prefetch"}, true
+	}
+
+	return Crawler{}, false
+}
@@ -0,0 +1,156 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cedi/urlshortener/pkg/safehttp"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+const userAgent = "urlshortener-healthcheck/1.0"
+
+// Result is the outcome of a single health check
+type Result struct {
+	// StatusCode is the HTTP status code returned by the target. 0 if the target couldn't be reached
+	StatusCode int
+
+	// Latency is the time it took the target to respond
+	Latency time.Duration
+
+	// Err is set if the target couldn't be reached
+	Err error
+}
+
+// Reachable returns true if the target responded with a non-error status code
+func (r *Result) Reachable() bool {
+	return r.Err == nil && r.StatusCode > 0 && r.StatusCode < http.StatusBadRequest
+}
+
+// RateLimitedError is returned by Check if the host of the target was checked too often
+type RateLimitedError struct {
+	Host       string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("health checks of host %s are rate limited, retry after %s", e.Host, e.RetryAfter)
+}
+
+// Checker probes targets using HEAD requests, falling back to GET if the target doesn't support HEAD.
+// Checks are rate limited per host, so that many shortlinks to the same host don't flood it with requests.
+// Only publicly routable addresses are probed, so that targets can't be used to scan the cluster
+type Checker struct {
+	httpClient *http.Client
+	tracer     trace.Tracer
+
+	limit    rate.Limit
+	burst    int
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewChecker creates a new Checker which allows perHost checks per second with a burst of burst checks per host
+func NewChecker(tracer trace.Tracer, timeout time.Duration, perHost float64, burst int) *Checker {
+	return &Checker{
+		httpClient: safehttp.NewClient(timeout),
+		tracer:     tracer,
+		limit:      rate.Limit(perHost),
+		burst:      burst,
+		limiters:   make(map[string]*rate.Limiter),
+	}
+}
+
+// Check probes target. If the host of the target was checked too often, a *RateLimitedError is returned
+// and the target is not probed at all
+func (c *Checker) Check(ct context.Context, target string) (*Result, error) {
+	ctx, span := c.tracer.Start(ct, "Checker.Check", trace.WithAttributes(attribute.String("target", target)))
+	defer span.End()
+
+	target = normalizeTarget(target)
+
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return &Result{Err: fmt.Errorf("invalid target %q: only absolute http and https URLs can be checked", target)}, nil
+	}
+
+	reservation := c.limiter(u.Hostname()).Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return nil, &RateLimitedError{Host: u.Hostname(), RetryAfter: delay}
+	}
+
+	result := c.probe(ctx, http.MethodHead, target)
+
+	// Some servers don't implement HEAD, retry with a GET before we consider the target broken
+	if result.StatusCode == http.StatusMethodNotAllowed || result.StatusCode == http.StatusNotImplemented {
+		result = c.probe(ctx, http.MethodGet, target)
+	}
+
+	span.SetAttributes(
+		attribute.Int("status_code", result.StatusCode),
+		attribute.Int64("latency_ms", result.Latency.Milliseconds()),
+	)
+
+	if result.Err != nil {
+		span.RecordError(result.Err)
+	}
+
+	return result, nil
+}
+
+// normalizeTarget prefixes targets without scheme with http://, as the shortlinks redirect to them this way
+func normalizeTarget(target string) string {
+	if !strings.HasPrefix(target, "http") {
+		return "http://" + target
+	}
+
+	return target
+}
+
+func (c *Checker) probe(ctx context.Context, method string, target string) *Result {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return &Result{Err: errors.Wrap(err, "Failed to build request")}
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	latency := time.Since(start)
+
+	if err != nil {
+		return &Result{Latency: latency, Err: err}
+	}
+	defer resp.Body.Close()
+
+	// Drain a bit of the body so the connection can be reused
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+
+	return &Result{StatusCode: resp.StatusCode, Latency: latency}
+}
+
+func (c *Checker) limiter(host string) *rate.Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	host = strings.ToLower(host)
+
+	limiter, ok := c.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(c.limit, c.burst)
+		c.limiters[host] = limiter
+	}
+
+	return limiter
+}
@@ -0,0 +1,131 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestChecker returns a Checker which may probe the loopback address of httptest servers
+func newTestChecker(perHost float64, burst int) *Checker {
+	checker := NewChecker(trace.NewNoopTracerProvider().Tracer("test"), time.Second, perHost, burst)
+	checker.httpClient = &http.Client{Timeout: time.Second}
+
+	return checker
+}
+
+func TestNormalizeTarget(t *testing.T) {
+	tests := map[string]string{
+		"example.com/path":     "http://example.com/path",
+		"http://example.com":   "http://example.com",
+		"https://example.com/": "https://example.com/",
+	}
+
+	for target, want := range tests {
+		if got := normalizeTarget(target); got != want {
+			t.Errorf("normalizeTarget(%q) = %q, want %q", target, got, want)
+		}
+	}
+}
+
+func TestCheckFallsBackToGet(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+
+		if r.Header.Get("User-Agent") != userAgent {
+			t.Errorf("User-Agent = %q, want %q", r.Header.Get("User-Agent"), userAgent)
+		}
+
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	result, err := newTestChecker(10, 10).Check(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	if !result.Reachable() || result.StatusCode != http.StatusOK {
+		t.Errorf("Check() = %+v, want a reachable target", result)
+	}
+
+	if len(methods) != 2 || methods[0] != http.MethodHead || methods[1] != http.MethodGet {
+		t.Errorf("requests = %v, want HEAD and GET", methods)
+	}
+}
+
+func TestCheckWithoutScheme(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	result, err := newTestChecker(10, 10).Check(context.Background(), server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	if !result.Reachable() {
+		t.Errorf("Check() = %+v, want a reachable target", result)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	tests := []struct {
+		result Result
+		want   bool
+	}{
+		{Result{StatusCode: http.StatusOK}, true},
+		{Result{StatusCode: http.StatusMovedPermanently}, true},
+		{Result{StatusCode: http.StatusNotFound}, false},
+		{Result{StatusCode: http.StatusBadGateway}, false},
+		{Result{Err: errors.New("connection refused")}, false},
+		{Result{}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.result.Reachable(); got != test.want {
+			t.Errorf("%+v.Reachable() = %t, want %t", test.result, got, test.want)
+		}
+	}
+}
+
+func TestCheckRateLimitsPerHost(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	checker := newTestChecker(0.001, 1)
+
+	if _, err := checker.Check(context.Background(), server.URL); err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	var rateLimited *RateLimitedError
+	if _, err := checker.Check(context.Background(), server.URL+"/other"); !errors.As(err, &rateLimited) || rateLimited.RetryAfter <= 0 {
+		t.Fatalf("Check() = %v, want a *RateLimitedError", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("the target was probed %d times, want 1", requests)
+	}
+}
+
+func TestCheckInvalidTarget(t *testing.T) {
+	result, err := newTestChecker(10, 10).Check(context.Background(), "http:///path")
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	if result.Reachable() || result.Err == nil {
+		t.Errorf("Check() = %+v, want an invalid target", result)
+	}
+}
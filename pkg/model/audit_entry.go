@@ -0,0 +1,107 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+)
+
+const (
+	AuditOperationCreate   = "create"
+	AuditOperationUpdate   = "update"
+	AuditOperationDelete   = "delete"
+	AuditOperationRollback = "rollback"
+)
+
+// AuditEntry records a single change made to a ShortLink
+type AuditEntry struct {
+	// Operation is one of AuditOperationCreate, AuditOperationUpdate, AuditOperationDelete or AuditOperationRollback
+	Operation string `json:"operation"`
+
+	// Actor is the GitHub user who performed the operation
+	Actor string `json:"actor"`
+
+	// SourceIP is the IP address the request originated from
+	SourceIP string `json:"sourceIP,omitempty"`
+
+	// Timestamp is the time the operation was performed
+	Timestamp time.Time `json:"timestamp"`
+
+	// Changes are the fields of the ShortLinkSpec modified by the operation
+	Changes []SpecChange `json:"changes,omitempty"`
+}
+
+// SpecChange is the value of a ShortLinkSpec field before and after an operation
+type SpecChange struct {
+	// Field is the JSON name of the changed field, e.g. "target"
+	Field string `json:"field"`
+
+	// Before is the value before the operation. nil if the field was not set
+	Before any `json:"before,omitempty"`
+
+	// After is the value after the operation. nil if the field was removed
+	After any `json:"after,omitempty"`
+}
+
+// NewAuditEntry creates an AuditEntry for an operation which changed the spec from before to after.
+// before is nil for a create, after is nil for a delete
+func NewAuditEntry(operation string, actor string, sourceIP string, before *v1alpha1.ShortLinkSpec, after *v1alpha1.ShortLinkSpec) AuditEntry {
+	return AuditEntry{
+		Operation: operation,
+		Actor:     actor,
+		SourceIP:  sourceIP,
+		Timestamp: time.Now().UTC(),
+		Changes:   DiffSpec(before, after),
+	}
+}
+
+// DiffSpec returns the fields which differ between before and after, sorted by field name
+func DiffSpec(before *v1alpha1.ShortLinkSpec, after *v1alpha1.ShortLinkSpec) []SpecChange {
+	beforeFields := specFields(before)
+	afterFields := specFields(after)
+
+	fields := make([]string, 0, len(beforeFields)+len(afterFields))
+	for field := range beforeFields {
+		fields = append(fields, field)
+	}
+
+	for field := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+
+	sort.Strings(fields)
+
+	changes := make([]SpecChange, 0)
+	for _, field := range fields {
+		if !reflect.DeepEqual(beforeFields[field], afterFields[field]) {
+			changes = append(changes, SpecChange{
+				Field:  field,
+				Before: beforeFields[field],
+				After:  afterFields[field],
+			})
+		}
+	}
+
+	return changes
+}
+
+// specFields returns the JSON representation of spec as a map of field name to value
+func specFields(spec *v1alpha1.ShortLinkSpec) map[string]any {
+	fields := make(map[string]any)
+	if spec == nil {
+		return fields
+	}
+
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return fields
+	}
+
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
@@ -0,0 +1,20 @@
+package model
+
+import "fmt"
+
+// QuotaExceededError is returned if a user already owns the maximum number of ShortLinks
+type QuotaExceededError struct {
+	Username string
+	Limit    int
+}
+
+func NewQuotaExceededError(username string, limit int) *QuotaExceededError {
+	return &QuotaExceededError{
+		Username: username,
+		Limit:    limit,
+	}
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("User '%s' already owns the maximum of %d ShortLinks", e.Username, e.Limit)
+}
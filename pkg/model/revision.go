@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+)
+
+// Revision is a spec a ShortLink had at some point in time
+type Revision struct {
+	// Revision is the number of the revision, starting at 1. The highest revision is the current spec
+	Revision int64 `json:"revision"`
+
+	// ChangedBy is the GitHub user who created the revision
+	ChangedBy string `json:"changedBy,omitempty"`
+
+	// Timestamp is the time the revision was created
+	Timestamp time.Time `json:"timestamp"`
+
+	// Spec is the spec of the ShortLink at this revision
+	Spec v1alpha1.ShortLinkSpec `json:"spec"`
+}
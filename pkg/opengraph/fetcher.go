@@ -0,0 +1,163 @@
+package opengraph
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cedi/urlshortener/pkg/safehttp"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/html"
+)
+
+const userAgent = "urlshortener-opengraph/1.0"
+
+// maxBodySize is how much of the target is read at most. The metadata is in the head, which comes first
+const maxBodySize = 1 << 20
+
+// Metadata is the Open Graph metadata of a page
+type Metadata struct {
+	Title       string
+	Description string
+	Image       string
+}
+
+// Fetcher fetches the Open Graph metadata of targets
+type Fetcher struct {
+	httpClient *http.Client
+	tracer     trace.Tracer
+}
+
+// NewFetcher creates a new Fetcher which gives up on a target after timeout. Targets which resolve to
+// cluster internal or otherwise non-public addresses are not fetched
+func NewFetcher(tracer trace.Tracer, timeout time.Duration) *Fetcher {
+	return &Fetcher{
+		httpClient: safehttp.NewClient(timeout),
+		tracer:     tracer,
+	}
+}
+
+// Fetch returns the Open Graph metadata of target. Pages without Open Graph metadata fall back to their
+// title and description
+func (f *Fetcher) Fetch(ct context.Context, target string) (*Metadata, error) {
+	ctx, span := f.tracer.Start(ct, "Fetcher.Fetch", trace.WithAttributes(attribute.String("target", target)))
+	defer span.End()
+
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid target %q: only absolute http and https URLs can be fetched", target)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to build request")
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("status_code", resp.StatusCode))
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("target responded with %d", resp.StatusCode)
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "text/html" {
+		return nil, fmt.Errorf("target is not a HTML page but %q", resp.Header.Get("Content-Type"))
+	}
+
+	// Relative image URLs are resolved against the URL of the page after following redirects
+	return parse(io.LimitReader(resp.Body, maxBodySize), resp.Request.URL), nil
+}
+
+// parse reads the metadata from the head of the HTML document in r
+func parse(r io.Reader, base *url.URL) *Metadata {
+	metadata := &Metadata{}
+	var title, description string
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+
+		if tokenType == html.StartTagToken && token.Data == "body" || tokenType == html.EndTagToken && token.Data == "head" {
+			break
+		}
+
+		if tokenType == html.StartTagToken && token.Data == "title" && title == "" {
+			if tokenizer.Next() == html.TextToken {
+				title = strings.TrimSpace(tokenizer.Token().Data)
+			}
+			continue
+		}
+
+		if token.Data != "meta" || (tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken) {
+			continue
+		}
+
+		var property, content string
+		for _, attr := range token.Attr {
+			switch attr.Key {
+			case "property", "name":
+				property = strings.ToLower(attr.Val)
+			case "content":
+				content = strings.TrimSpace(attr.Val)
+			}
+		}
+
+		switch property {
+		case "og:title":
+			metadata.Title = content
+		case "og:description":
+			metadata.Description = content
+		case "og:image", "og:image:url", "og:image:secure_url":
+			if metadata.Image == "" {
+				metadata.Image = resolve(base, content)
+			}
+		case "twitter:image":
+			if metadata.Image == "" {
+				metadata.Image = resolve(base, content)
+			}
+		case "description":
+			description = content
+		}
+	}
+
+	if metadata.Title == "" {
+		metadata.Title = title
+	}
+
+	if metadata.Description == "" {
+		metadata.Description = description
+	}
+
+	return metadata
+}
+
+// resolve returns ref as an absolute URL relative to base, or an empty string if ref isn't a http(s) URL
+func resolve(base *url.URL, ref string) string {
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	return u.String()
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// sweepInterval is how often buckets which are full again are removed, so keys seen once don't use memory forever
+const sweepInterval = time.Minute
+
+// Limiter rate limits requests per key, e.g. per client IP, using a token bucket per key
+type Limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// New returns a Limiter which allows perSecond requests per key with bursts of up to burst requests.
+// A perSecond of 0 disables rate limiting
+func New(perSecond float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		limit:     rate.Limit(perSecond),
+		burst:     burst,
+		buckets:   make(map[string]*rate.Limiter),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow reports whether a request for key is allowed. If it isn't, the time until the next request
+// for key is allowed is returned
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.limit == 0 {
+		return true, 0
+	}
+
+	reservation := l.bucket(key).Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return false, delay
+	}
+
+	return true, 0
+}
+
+func (l *Limiter) bucket(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.lastSweep) > sweepInterval {
+		// A full bucket behaves exactly like a new one, so it can be dropped
+		for k, bucket := range l.buckets {
+			if bucket.Tokens() >= float64(l.burst) {
+				delete(l.buckets, k)
+			}
+		}
+
+		l.lastSweep = time.Now()
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(l.limit, l.burst)
+		l.buckets[key] = bucket
+	}
+
+	return bucket
+}
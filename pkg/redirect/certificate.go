@@ -0,0 +1,94 @@
+package redirect
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CertificateGVK is the GroupVersionKind of the cert-manager Certificate
+var CertificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// NewCertificate returns an empty cert-manager Certificate
+func NewCertificate() *unstructured.Unstructured {
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(CertificateGVK)
+	return cert
+}
+
+// NewRedirectCertificate returns a Certificate for all source hosts of redirect, issued by the issuer of its
+// TLS spec and stored in the secret the rendered objects reference
+func NewRedirectCertificate(redirect *v1alpha1.Redirect) *unstructured.Unstructured {
+	issuer := redirect.Spec.TLS.Issuer
+
+	issuerRef := map[string]any{
+		"name": issuer.Name,
+		"kind": issuer.Kind,
+	}
+
+	if issuer.Kind == "" {
+		issuerRef["kind"] = "ClusterIssuer"
+	}
+
+	if issuer.Group != "" {
+		issuerRef["group"] = issuer.Group
+	}
+
+	dnsNames := make([]any, 0)
+	for _, host := range redirect.Hosts() {
+		dnsNames = append(dnsNames, host)
+	}
+
+	cert := NewCertificate()
+	cert.SetName(tlsSecretName(redirect))
+	cert.SetNamespace(redirect.Namespace)
+	cert.SetLabels(GetLabelsForRedirect(redirect.Name))
+
+	cert.Object["spec"] = map[string]any{
+		"secretName": tlsSecretName(redirect),
+		"dnsNames":   dnsNames,
+		"issuerRef":  issuerRef,
+	}
+
+	return cert
+}
+
+// CertificateStatus returns the time the issued certificate expires, or nil if none was issued yet, and an error
+// describing why the certificate isn't ready, or nil if it is ready
+func CertificateStatus(cert *unstructured.Unstructured) (*time.Time, error) {
+	var notAfter *time.Time
+
+	if value, found, _ := unstructured.NestedString(cert.Object, "status", "notAfter"); found {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			notAfter = &t
+		}
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(cert.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+
+		if condition["status"] != "True" {
+			return notAfter, fmt.Errorf("certificate %s is not ready: %v", cert.GetName(), condition["message"])
+		}
+
+		if notAfter != nil && !notAfter.After(time.Now()) {
+			return notAfter, fmt.Errorf("certificate %s expired at %s", cert.GetName(), notAfter.Format(time.RFC3339))
+		}
+
+		return notAfter, nil
+	}
+
+	return notAfter, fmt.Errorf("certificate %s is not issued yet", cert.GetName())
+}
@@ -0,0 +1,141 @@
+package redirect
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ContourHTTPProxyGVK is the GroupVersionKind of the Contour HTTPProxy
+var ContourHTTPProxyGVK = schema.GroupVersionKind{
+	Group:   "projectcontour.io",
+	Version: "v1",
+	Kind:    "HTTPProxy",
+}
+
+// ContourRenderer renders a HTTPProxy per source host with a route and requestRedirectPolicy per rule.
+// Contour can't rewrite paths using regular expressions, so only prefix rules are supported
+type ContourRenderer struct{}
+
+func (r *ContourRenderer) Render(redirect *v1alpha1.Redirect) ([]client.Object, error) {
+	rules, err := Rules(redirect)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]any, 0, len(rules))
+	for _, rule := range rules {
+		if rule.IsRegex() {
+			return nil, fmt.Errorf("the %s renderer doesn't support regex rules", RendererContour)
+		}
+
+		policy, err := contourRedirectPolicy(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, map[string]any{
+			"conditions": []any{
+				map[string]any{"prefix": rule.Prefix},
+			},
+			"requestRedirectPolicy": policy,
+		})
+	}
+
+	objects := make([]client.Object, 0)
+	for i, host := range redirect.Hosts() {
+		virtualHost := map[string]any{
+			"fqdn": host,
+		}
+
+		if redirect.Spec.TLS.Enable {
+			virtualHost["tls"] = map[string]any{
+				"secretName": tlsSecretName(redirect),
+			}
+		}
+
+		proxy := &unstructured.Unstructured{}
+		proxy.SetGroupVersionKind(ContourHTTPProxyGVK)
+		proxy.SetNamespace(redirect.Namespace)
+		proxy.SetLabels(GetLabelsForRedirect(redirect.Name))
+
+		// A HTTPProxy only has a single virtual host
+		proxy.SetName(redirect.Name)
+		if i > 0 {
+			proxy.SetName(fmt.Sprintf("%s-%s", redirect.Name, strings.ReplaceAll(host, ".", "-")))
+		}
+
+		if redirect.Spec.TLS.Enable {
+			proxy.SetAnnotations(redirect.Spec.TLS.Annotations)
+		}
+
+		spec := map[string]any{
+			"virtualhost": virtualHost,
+			"routes":      routes,
+		}
+
+		if redirect.Spec.IngressClassName != "" {
+			spec["ingressClassName"] = redirect.Spec.IngressClassName
+		}
+
+		proxy.Object["spec"] = spec
+
+		objects = append(objects, proxy)
+	}
+
+	return objects, nil
+}
+
+// contourRedirectPolicy returns the requestRedirectPolicy of rule. Contour only supports 301 and 302,
+// like the Gateway API
+func contourRedirectPolicy(rule Rule) (map[string]any, error) {
+	u, err := url.Parse(rule.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := map[string]any{
+		"scheme":     u.Scheme,
+		"hostname":   u.Hostname(),
+		"statusCode": int64(gatewayStatusCode(rule.Code)),
+	}
+
+	if u.Port() != "" {
+		port, err := strconv.ParseInt(u.Port(), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		policy["port"] = port
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	if rule.KeepPath {
+		// prefix replaces the matched prefix and keeps the rest of the path
+		if path != rule.Prefix {
+			policy["prefix"] = path
+		}
+	} else {
+		policy["path"] = path
+	}
+
+	return policy, nil
+}
+
+func (r *ContourRenderer) Types() []client.Object {
+	proxy := &unstructured.Unstructured{}
+	proxy.SetGroupVersionKind(ContourHTTPProxyGVK)
+
+	return []client.Object{proxy}
+}
@@ -0,0 +1,180 @@
+package redirect
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// HTTPRouteGVK is the GroupVersionKind of the Gateway API HTTPRoute
+var HTTPRouteGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1beta1",
+	Kind:    "HTTPRoute",
+}
+
+// NewHTTPRoute returns an empty HTTPRoute, e.g. to Get an existing HTTPRoute into
+func NewHTTPRoute() *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	return route
+}
+
+// NewRedirectHTTPRoute takes an existing HTTPRoute and updates it, or creates an entirely new HTTPRoute.
+// The HTTPRoute matches the rules of the redirect on all source hosts and redirects them using RequestRedirect filters.
+// parentRefs are used if the redirect doesn't specify its own Gateways. Native redirects are routed to the
+// native backend instead
+func NewRedirectHTTPRoute(route *unstructured.Unstructured, redirect *v1alpha1.Redirect, parentRefs []v1alpha1.GatewayReference, native NativeBackend) (*unstructured.Unstructured, error) {
+	if route == nil {
+		route = NewHTTPRoute()
+	}
+
+	if len(redirect.Spec.ParentRefs) > 0 {
+		parentRefs = redirect.Spec.ParentRefs
+	}
+
+	if len(parentRefs) == 0 {
+		return nil, fmt.Errorf("no Gateway to attach the HTTPRoute to: set spec.parentRefs or start the urlshortener with --gateway")
+	}
+
+	routeRules := []any{
+		map[string]any{
+			"backendRefs": []any{
+				map[string]any{
+					"name": native.Name,
+					"port": int64(native.Port),
+				},
+			},
+		},
+	}
+
+	if !redirect.Spec.Native {
+		rules, err := Rules(redirect)
+		if err != nil {
+			return nil, err
+		}
+
+		routeRules = make([]any, 0, len(rules))
+		for _, rule := range rules {
+			if rule.IsRegex() {
+				return nil, fmt.Errorf("the %s backend doesn't support regex rules", v1alpha1.RedirectBackendHTTPRoute)
+			}
+
+			requestRedirect, err := newRequestRedirect(rule)
+			if err != nil {
+				return nil, err
+			}
+
+			routeRules = append(routeRules, map[string]any{
+				"matches": []any{
+					map[string]any{
+						"path": map[string]any{
+							"type":  "PathPrefix",
+							"value": rule.Prefix,
+						},
+					},
+				},
+				"filters": []any{
+					map[string]any{
+						"type":            "RequestRedirect",
+						"requestRedirect": requestRedirect,
+					},
+				},
+			})
+		}
+	}
+
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	route.SetName(redirect.Name)
+	route.SetNamespace(redirect.Namespace)
+	route.SetLabels(GetLabelsForRedirect(redirect.Name))
+
+	refs := make([]any, 0, len(parentRefs))
+	for _, parentRef := range parentRefs {
+		ref := map[string]any{
+			"name": parentRef.Name,
+		}
+
+		if parentRef.Namespace != "" {
+			ref["namespace"] = parentRef.Namespace
+		}
+
+		if parentRef.SectionName != "" {
+			ref["sectionName"] = parentRef.SectionName
+		}
+
+		refs = append(refs, ref)
+	}
+
+	hostnames := make([]any, 0)
+	for _, host := range redirect.Hosts() {
+		hostnames = append(hostnames, host)
+	}
+
+	route.Object["spec"] = map[string]any{
+		"parentRefs": refs,
+		"hostnames":  hostnames,
+		"rules":      routeRules,
+	}
+
+	return route, nil
+}
+
+// newRequestRedirect builds the HTTPRequestRedirectFilter for rule. Rules keeping the path replace the
+// matched prefix, all other rules replace the full path
+func newRequestRedirect(rule Rule) (map[string]any, error) {
+	u, err := url.Parse(rule.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target %q: %w", rule.Target, err)
+	}
+
+	requestRedirect := map[string]any{
+		"scheme":     u.Scheme,
+		"hostname":   u.Hostname(),
+		"statusCode": int64(gatewayStatusCode(rule.Code)),
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	if !rule.KeepPath {
+		requestRedirect["path"] = map[string]any{
+			"type":            "ReplaceFullPath",
+			"replaceFullPath": path,
+		}
+	} else if path != rule.Prefix {
+		requestRedirect["path"] = map[string]any{
+			"type":               "ReplacePrefixMatch",
+			"replacePrefixMatch": path,
+		}
+	}
+
+	if u.Port() != "" {
+		port, err := strconv.ParseInt(u.Port(), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in target %q: %w", rule.Target, err)
+		}
+
+		requestRedirect["port"] = port
+	}
+
+	return requestRedirect, nil
+}
+
+// gatewayStatusCode maps code to the status codes supported by the Gateway API RequestRedirect filter,
+// which only supports 301 and 302
+func gatewayStatusCode(code int) int {
+	switch code {
+	case 301, 308:
+		return 301
+	default:
+		return 302
+	}
+}
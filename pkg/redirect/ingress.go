@@ -2,6 +2,7 @@ package redirect
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -9,44 +10,111 @@ import (
 
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// NewRedirectIngress takes an existing ingress and updates it, or creates an entirely new *networkingv1.Ingress object
-func NewRedirectIngress(ing *networkingv1.Ingress, redirect *v1alpha1.Redirect) *networkingv1.Ingress {
-	pathTypePrefix := networkingv1.PathTypePrefix
+// NginxRenderer renders an Ingress per rule using the permanent-redirect annotations of ingress-nginx.
+// The path of the Ingress is the regex of the rule, so the redirect can reference its capture groups
+type NginxRenderer struct{}
 
-	if ing == nil {
-		ing = &networkingv1.Ingress{}
+func (r *NginxRenderer) Render(redirect *v1alpha1.Redirect) ([]client.Object, error) {
+	rules, err := Rules(redirect)
+	if err != nil {
+		return nil, err
 	}
 
+	objects := make([]client.Object, 0, len(rules))
+	for _, rule := range rules {
+		target := rule.Replacement()
+		if rule.KeepPath {
+			target += "$is_args$args"
+		}
+
+		// ingress-nginx anchors regex paths itself and Ingress paths have to start with /
+		ing := newIngress(redirect, rule.Name, strings.TrimPrefix(rule.Regex, "^"), networkingv1.PathTypeImplementationSpecific, map[string]string{
+			"nginx.ingress.kubernetes.io/use-regex":               "true",
+			"nginx.ingress.kubernetes.io/permanent-redirect":      target,
+			"nginx.ingress.kubernetes.io/permanent-redirect-code": fmt.Sprintf("%d", rule.Code),
+		})
+
+		objects = append(objects, ing)
+	}
+
+	return objects, nil
+}
+
+func (r *NginxRenderer) Types() []client.Object {
+	return []client.Object{&networkingv1.Ingress{}}
+}
+
+// HAProxyRenderer renders an Ingress using the request-redirect annotations of the HAProxy ingress controller
+type HAProxyRenderer struct{}
+
+func (r *HAProxyRenderer) Render(redirect *v1alpha1.Redirect) ([]client.Object, error) {
+	if len(redirect.Spec.Rules) > 0 {
+		return nil, fmt.Errorf("the %s renderer doesn't support rules, it can only redirect whole hosts", RendererHAProxy)
+	}
+
+	// request-redirect only accepts a host (and port) and always keeps the path of the request. Redirects which
+	// would behave differently are refused instead of being rendered
+	if hasScheme(redirect.Spec.Target) && !redirect.Spec.PreservePath {
+		return nil, fmt.Errorf("the %s renderer always preserves the path, set preservePath or use another renderer", RendererHAProxy)
+	}
+
+	u, err := url.Parse(normalizeUrl(redirect.Spec.Target))
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(u.Path, "/") != "" || u.RawQuery != "" {
+		return nil, fmt.Errorf("the %s renderer can only redirect to a host, not to the path of target %q", RendererHAProxy, redirect.Spec.Target)
+	}
+
+	target := u.Host
+
+	ing := newIngress(redirect, redirect.Name, "/", networkingv1.PathTypePrefix, map[string]string{
+		"haproxy.org/request-redirect":      target,
+		"haproxy.org/request-redirect-code": fmt.Sprintf("%d", redirect.Spec.Code),
+	})
+
+	return []client.Object{ing}, nil
+}
+
+func (r *HAProxyRenderer) Types() []client.Object {
+	return []client.Object{&networkingv1.Ingress{}}
+}
+
+// newIngress returns an Ingress named name, routing path on all source hosts of redirect and carrying the given
+// annotations. The redirect is performed by the ingress controller based on the annotations, the http-svc backend
+// never receives requests
+func newIngress(redirect *v1alpha1.Redirect, name string, path string, pathType networkingv1.PathType, annotations map[string]string) *networkingv1.Ingress {
+	ing := &networkingv1.Ingress{}
+
 	ing.ObjectMeta = metav1.ObjectMeta{
-		Name:      redirect.Name,
-		Namespace: redirect.Namespace,
-		Labels:    GetLabelsForRedirect(redirect.Name),
-		Annotations: map[string]string{
-			"nginx.ingress.kubernetes.io/rewrite-target":          "/",
-			"nginx.ingress.kubernetes.io/permanent-redirect":      normalizeUrl(redirect.Spec.Target),
-			"nginx.ingress.kubernetes.io/permanent-redirect-code": fmt.Sprintf("%d", redirect.Spec.Code),
-		},
+		Name:        name,
+		Namespace:   redirect.Namespace,
+		Labels:      GetLabelsForRedirect(redirect.Name),
+		Annotations: annotations,
 	}
 
 	ing.Spec = networkingv1.IngressSpec{
 		IngressClassName: &redirect.Spec.IngressClassName,
-		Rules: []networkingv1.IngressRule{
-			{
-				Host: redirect.Spec.Source,
-				IngressRuleValue: networkingv1.IngressRuleValue{
-					HTTP: &networkingv1.HTTPIngressRuleValue{
-						Paths: []networkingv1.HTTPIngressPath{
-							{
-								Path:     "/",
-								PathType: &pathTypePrefix,
-								Backend: networkingv1.IngressBackend{
-									Service: &networkingv1.IngressServiceBackend{
-										Name: "http-svc",
-										Port: networkingv1.ServiceBackendPort{
-											Number: 80,
-										},
+	}
+
+	for _, host := range redirect.Hosts() {
+		ing.Spec.Rules = append(ing.Spec.Rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{
+						{
+							Path:     path,
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: "http-svc",
+									Port: networkingv1.ServiceBackendPort{
+										Number: 80,
 									},
 								},
 							},
@@ -54,14 +122,14 @@ func NewRedirectIngress(ing *networkingv1.Ingress, redirect *v1alpha1.Redirect)
 					},
 				},
 			},
-		},
+		})
 	}
 
 	if redirect.Spec.TLS.Enable == true {
 		ing.Spec.TLS = []networkingv1.IngressTLS{
 			{
-				Hosts:      []string{redirect.Spec.Source},
-				SecretName: fmt.Sprintf("%s-redirect-secret", strings.ReplaceAll(redirect.Spec.Source, ".", "-")),
+				Hosts:      redirect.Hosts(),
+				SecretName: tlsSecretName(redirect),
 			},
 		}
 
@@ -92,13 +160,22 @@ func GetIngressNames(ingresses []networkingv1.Ingress) []string {
 	return ingressNames
 }
 
+// tlsSecretName returns the name of the secret holding the certificate of the source host of redirect
+func tlsSecretName(redirect *v1alpha1.Redirect) string {
+	return fmt.Sprintf("%s-redirect-secret", strings.ReplaceAll(redirect.Spec.Source, ".", "-"))
+}
+
+// normalizeUrl prepends http:// to the target if the protocol is not indicated by `://`
 func normalizeUrl(redirectTarget string) string {
-	// Regex Matches if the URL contains `://` to indicate the protocol
-	r := regexp.MustCompile(`^(.+)(:\/\/).*$`)
-	if !r.Match([]byte(redirectTarget)) {
-		// if the protocol is not indicated by `://` this prepends `http://`
-		redirectTarget = fmt.Sprintf("http://%s$request_uri", redirectTarget)
+	if !hasScheme(redirectTarget) {
+		redirectTarget = fmt.Sprintf("http://%s", redirectTarget)
 	}
 
 	return redirectTarget
 }
+
+// hasScheme returns true if the URL contains `://` to indicate the protocol
+func hasScheme(redirectTarget string) bool {
+	r := regexp.MustCompile(`^(.+)(:\/\/).*$`)
+	return r.Match([]byte(redirectTarget))
+}
@@ -0,0 +1,112 @@
+package redirect
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NativeBackend is the Service of the urlshortener, which serves native Redirects
+type NativeBackend struct {
+	Name string
+	Port int32
+}
+
+// NewNativeIngress returns an Ingress routing all requests to the source hosts of redirect to the urlshortener
+func NewNativeIngress(redirect *v1alpha1.Redirect, backend NativeBackend) *networkingv1.Ingress {
+	ing := newIngress(redirect, redirect.Name, "/", networkingv1.PathTypePrefix, map[string]string{})
+
+	for _, rule := range ing.Spec.Rules {
+		rule.HTTP.Paths[0].Backend = networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: backend.Name,
+				Port: networkingv1.ServiceBackendPort{
+					Number: backend.Port,
+				},
+			},
+		}
+	}
+
+	return ing
+}
+
+// maxCachedRules is the number of Redirects the rules are cached for by a Resolver
+const maxCachedRules = 1024
+
+// Resolver resolves the targets of requests to native Redirects. The rules of a Redirect are only built and compiled
+// once per generation of the Redirect instead of on every request
+type Resolver struct {
+	mu    sync.Mutex
+	rules map[types.UID]cachedRules
+}
+
+type cachedRules struct {
+	generation int64
+	rules      []Rule
+}
+
+// NewResolver creates a new Resolver
+func NewResolver() *Resolver {
+	return &Resolver{
+		rules: make(map[types.UID]cachedRules),
+	}
+}
+
+// ResolveTarget returns the URL and status code a request to a native redirect is redirected with,
+// according to the first rule of the redirect matching the request
+func (r *Resolver) ResolveTarget(redirect *v1alpha1.Redirect, request *url.URL) (string, int, error) {
+	rules, err := r.rulesOf(redirect)
+	if err != nil {
+		return "", 0, err
+	}
+
+	target, rule := Match(rules, request)
+	if rule == nil {
+		return "", 0, fmt.Errorf("no rule of redirect %s matches %s", redirect.Name, request.Path)
+	}
+
+	return target, rule.Code, nil
+}
+
+func (r *Resolver) rulesOf(redirect *v1alpha1.Redirect) ([]Rule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.rules[redirect.UID]; ok && cached.generation == redirect.Generation {
+		return cached.rules, nil
+	}
+
+	rules, err := Rules(redirect)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rules of deleted Redirects are never removed, start over instead of growing forever
+	if len(r.rules) >= maxCachedRules {
+		r.rules = make(map[types.UID]cachedRules)
+	}
+
+	r.rules[redirect.UID] = cachedRules{generation: redirect.Generation, rules: rules}
+
+	return rules, nil
+}
+
+// CoversHost returns true if source, which may be a wildcard host like *.example.com, matches host
+func CoversHost(source string, host string) bool {
+	source = strings.ToLower(source)
+	host = strings.ToLower(host)
+
+	if suffix, ok := strings.CutPrefix(source, "*"); ok {
+		// A wildcard only matches a single label, like in Ingress rules
+		label, found := strings.CutSuffix(host, suffix)
+		return found && label != "" && !strings.Contains(label, ".")
+	}
+
+	return source == host
+}
@@ -0,0 +1,125 @@
+package redirect
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	RendererNginx   = "nginx"
+	RendererTraefik = "traefik"
+	RendererHAProxy = "haproxy"
+	RendererContour = "contour"
+)
+
+// Renderer renders the objects implementing a Redirect for a specific ingress controller
+type Renderer interface {
+	// Render returns the objects implementing redirect
+	Render(redirect *v1alpha1.Redirect) ([]client.Object, error)
+
+	// Types returns empty objects of all types returned by Render, so they can be watched
+	Types() []client.Object
+}
+
+var availableRenderers = map[string]Renderer{
+	RendererNginx:   &NginxRenderer{},
+	RendererTraefik: &TraefikRenderer{},
+	RendererHAProxy: &HAProxyRenderer{},
+	RendererContour: &ContourRenderer{},
+}
+
+// AvailableRenderers returns the names of all renderers, sorted by name
+func AvailableRenderers() []string {
+	names := make([]string, 0, len(availableRenderers))
+	for name := range availableRenderers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Renderers selects the Renderer of a Redirect by its IngressClassName
+type Renderers struct {
+	enabled map[string]Renderer
+	classes map[string]string
+}
+
+// NewRenderers enables the renderers with the given names. classes maps ingress class names to renderer names.
+// Ingress classes which are not mapped use the renderer of the same name if it is enabled, and the nginx
+// renderer otherwise. The nginx renderer is always enabled
+func NewRenderers(enabled []string, classes map[string]string) (*Renderers, error) {
+	r := &Renderers{
+		enabled: map[string]Renderer{
+			RendererNginx: availableRenderers[RendererNginx],
+		},
+		classes: make(map[string]string),
+	}
+
+	for _, name := range enabled {
+		renderer, ok := availableRenderers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown renderer %q, available renderers are %v", name, AvailableRenderers())
+		}
+
+		r.enabled[name] = renderer
+	}
+
+	for class, name := range classes {
+		if _, ok := r.enabled[name]; !ok {
+			return nil, fmt.Errorf("ingress class %q is mapped to renderer %q which is not enabled", class, name)
+		}
+
+		r.classes[class] = name
+	}
+
+	return r, nil
+}
+
+// For returns the renderer for the given ingress class
+func (r *Renderers) For(ingressClassName string) Renderer {
+	if name, ok := r.classes[ingressClassName]; ok {
+		return r.enabled[name]
+	}
+
+	if renderer, ok := r.enabled[ingressClassName]; ok {
+		return renderer
+	}
+
+	return r.enabled[RendererNginx]
+}
+
+// Types returns empty objects of all types rendered by the enabled renderers, without duplicates
+func (r *Renderers) Types() []client.Object {
+	seen := make(map[string]bool)
+	types := make([]client.Object, 0)
+
+	names := make([]string, 0, len(r.enabled))
+	for name := range r.enabled {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, obj := range r.enabled[name].Types() {
+			key := reflect.TypeOf(obj).String()
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				key = u.GroupVersionKind().String()
+			}
+
+			if !seen[key] {
+				seen[key] = true
+				types = append(types, obj)
+			}
+		}
+	}
+
+	return types
+}
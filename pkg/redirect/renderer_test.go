@@ -0,0 +1,128 @@
+package redirect
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// goldenRedirects are rendered by every renderer. A renderer which doesn't support a Redirect must refuse it
+var goldenRedirects = map[string]v1alpha1.RedirectSpec{
+	"host": {
+		Source:           "old.example.com",
+		Target:           "new.example.com",
+		Code:             308,
+		IngressClassName: "test",
+	},
+	"preserve-path": {
+		Source:           "old.example.com",
+		Sources:          []string{"www.old.example.com"},
+		Target:           "https://new.example.com",
+		PreservePath:     true,
+		Code:             301,
+		IngressClassName: "test",
+	},
+	"target-path": {
+		Source:           "old.example.com",
+		Target:           "https://new.example.com/landing",
+		Code:             302,
+		IngressClassName: "test",
+	},
+	"prefix-rules": {
+		Source: "old.example.com",
+		Target: "https://new.example.com",
+		Rules: []v1alpha1.RedirectRule{
+			{Path: "/docs/", Target: "https://docs.example.com/", Code: 301},
+			{Path: "/blog", Target: "https://blog.example.com/posts"},
+		},
+		Code:             308,
+		IngressClassName: "test",
+		TLS: v1alpha1.TLSSpec{
+			Enable:      true,
+			Annotations: map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt"},
+		},
+	},
+	"regex-rules": {
+		Source: "old.example.com",
+		Target: "https://new.example.com",
+		Rules: []v1alpha1.RedirectRule{
+			{Regex: "^/users/([0-9]+)$", Target: "https://new.example.com/u/$1"},
+		},
+		Code:             307,
+		IngressClassName: "test",
+	},
+}
+
+func TestRenderGolden(t *testing.T) {
+	for _, rendererName := range AvailableRenderers() {
+		renderer := availableRenderers[rendererName]
+
+		for name, spec := range goldenRedirects {
+			t.Run(fmt.Sprintf("%s/%s", rendererName, name), func(t *testing.T) {
+				redirect := &v1alpha1.Redirect{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Spec:       *spec.DeepCopy(),
+				}
+
+				assertGolden(t, filepath.Join("testdata", rendererName, name+".yaml"), render(t, renderer, redirect))
+			})
+		}
+	}
+}
+
+// render returns the objects rendered for redirect as YAML documents, or the error of the renderer
+func render(t *testing.T, renderer Renderer, redirect *v1alpha1.Redirect) []byte {
+	t.Helper()
+
+	objects, err := renderer.Render(redirect)
+	if err != nil {
+		return []byte(fmt.Sprintf("error: %s\n", err))
+	}
+
+	out := []byte{}
+	for i, obj := range objects {
+		doc, err := yaml.Marshal(obj)
+		if err != nil {
+			t.Fatalf("failed to marshal %s: %v", obj.GetName(), err)
+		}
+
+		if i > 0 {
+			out = append(out, []byte("---\n")...)
+		}
+
+		out = append(out, doc...)
+	}
+
+	return out
+}
+
+func assertGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run with -update to create it: %v", err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("%s differs from the rendered objects, run with -update if the change is intended\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package redirect
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+)
+
+// Rule is a normalised rule of a Redirect, which the renderers translate into their own configuration.
+// Every rule is expressed as a regular expression, as well as a prefix if the rule is a prefix rule
+type Rule struct {
+	// Name is the name of the objects rendered for the rule if a renderer needs one object per rule
+	Name string
+
+	// Prefix is the path prefix matched by the rule. Empty for regex rules
+	Prefix string
+
+	// Regex matches the path of requests. For prefix rules it captures the rest of the path as $1
+	Regex string
+
+	// Target is the absolute URL requests are redirected to. Regex rules may reference capture groups of Regex
+	Target string
+
+	// KeepPath appends the rest of the path after Prefix and the query of the request to Target
+	KeepPath bool
+
+	// Code is the status code of the redirect
+	Code int
+
+	// regex is Regex compiled by Rules, so that matching requests doesn't compile it again
+	regex *regexp.Regexp
+}
+
+// Replacement returns Target with a reference to the capture group of Regex holding the rest of the path
+// if the rule keeps the path
+func (r Rule) Replacement() string {
+	if r.KeepPath {
+		return r.Target + "$1"
+	}
+
+	return r.Target
+}
+
+// IsRegex returns true if the rule can only be expressed as a regular expression
+func (r Rule) IsRegex() bool {
+	return r.Prefix == ""
+}
+
+// Rules returns the normalised rules of redirect in the order they are matched. The last rule redirects
+// all requests not matched by any of the Rules of the redirect to its Target
+func Rules(redirect *v1alpha1.Redirect) ([]Rule, error) {
+	rules := make([]Rule, 0, len(redirect.Spec.Rules)+1)
+
+	for i, spec := range redirect.Spec.Rules {
+		rule := Rule{
+			Name:   fmt.Sprintf("%s-%d", redirect.Name, i+1),
+			Target: normalizeUrl(spec.Target),
+			Code:   spec.Code,
+		}
+
+		if rule.Code == 0 {
+			rule.Code = redirect.Spec.Code
+		}
+
+		switch {
+		case spec.Path != "" && spec.Regex != "":
+			return nil, fmt.Errorf("rule %d: only one of path and regex can be set", i)
+
+		case spec.Path != "":
+			if !strings.HasPrefix(spec.Path, "/") {
+				return nil, fmt.Errorf("rule %d: path %q must start with /", i, spec.Path)
+			}
+
+			rule.Prefix = spec.Path
+			rule.Regex = prefixRegex(spec.Path)
+			rule.regex = regexp.MustCompile(rule.Regex)
+			rule.KeepPath = true
+
+		case spec.Regex != "":
+			if !strings.HasPrefix(spec.Regex, "^/") {
+				return nil, fmt.Errorf("rule %d: regex %q must start with ^/", i, spec.Regex)
+			}
+
+			regex, err := regexp.Compile(spec.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: invalid regex: %w", i, err)
+			}
+
+			rule.Regex = spec.Regex
+			rule.regex = regex
+
+		default:
+			return nil, fmt.Errorf("rule %d: one of path and regex must be set", i)
+		}
+
+		if _, err := url.Parse(rule.Target); err != nil {
+			return nil, fmt.Errorf("rule %d: invalid target %q: %w", i, spec.Target, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	fallback := Rule{
+		Name:     redirect.Name,
+		Prefix:   "/",
+		Regex:    prefixRegex("/"),
+		regex:    regexp.MustCompile(prefixRegex("/")),
+		Target:   normalizeUrl(redirect.Spec.Target),
+		KeepPath: !hasScheme(redirect.Spec.Target) || redirect.Spec.PreservePath,
+		Code:     redirect.Spec.Code,
+	}
+
+	if _, err := url.Parse(fallback.Target); err != nil {
+		return nil, fmt.Errorf("invalid target %q: %w", redirect.Spec.Target, err)
+	}
+
+	rules = append(rules, fallback)
+
+	// The rest of the path after a prefix ending with / starts without /, the target has to provide it
+	for i := range rules {
+		if rules[i].KeepPath && strings.HasSuffix(rules[i].Prefix, "/") && !strings.HasSuffix(rules[i].Target, "/") {
+			rules[i].Target += "/"
+		}
+	}
+
+	return rules, nil
+}
+
+// Match returns the target the request is redirected to by the first matching rule, and the rule itself.
+// The rules should be returned by Rules, which compiles their regular expressions once
+func Match(rules []Rule, request *url.URL) (string, *Rule) {
+	for i := range rules {
+		rule := &rules[i]
+
+		re := rule.regex
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(rule.Regex); err != nil {
+				continue
+			}
+		}
+
+		match := re.FindStringSubmatchIndex(request.Path)
+		if match == nil {
+			continue
+		}
+
+		if !rule.KeepPath {
+			return string(re.ExpandString(nil, rule.Target, request.Path, match)), rule
+		}
+
+		target := rule.Target + request.Path[match[2]:match[3]]
+		if request.RawQuery != "" {
+			if strings.Contains(target, "?") {
+				target += "&" + request.RawQuery
+			} else {
+				target += "?" + request.RawQuery
+			}
+		}
+
+		return target, rule
+	}
+
+	return "", nil
+}
+
+// prefixRegex returns a regular expression matching paths starting with prefix, capturing the rest of the path
+func prefixRegex(prefix string) string {
+	return fmt.Sprintf("^%s(.*)$", regexp.QuoteMeta(prefix))
+}
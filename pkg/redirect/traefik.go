@@ -0,0 +1,122 @@
+package redirect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cedi/urlshortener/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// TraefikMiddlewareGVK is the GroupVersionKind of the Traefik Middleware (Traefik >= 2.10)
+	TraefikMiddlewareGVK = schema.GroupVersionKind{
+		Group:   "traefik.io",
+		Version: "v1alpha1",
+		Kind:    "Middleware",
+	}
+
+	// TraefikIngressRouteGVK is the GroupVersionKind of the Traefik IngressRoute (Traefik >= 2.10)
+	TraefikIngressRouteGVK = schema.GroupVersionKind{
+		Group:   "traefik.io",
+		Version: "v1alpha1",
+		Kind:    "IngressRoute",
+	}
+)
+
+// TraefikRenderer renders a Middleware per rule performing the redirect using redirectRegex and an IngressRoute
+// applying the Middlewares to all requests to the source hosts. The IngressRoute uses the noop@internal
+// service of Traefik as backend, so no dummy service is required
+type TraefikRenderer struct{}
+
+func (r *TraefikRenderer) Render(redirect *v1alpha1.Redirect) ([]client.Object, error) {
+	rules, err := Rules(redirect)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := redirect.Hosts()
+	quotedHosts := make([]string, 0, len(hosts))
+	matchHosts := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		quotedHosts = append(quotedHosts, regexp.QuoteMeta(host))
+		matchHosts = append(matchHosts, fmt.Sprintf("Host(`%s`)", host))
+	}
+
+	objects := make([]client.Object, 0, len(rules)+1)
+	middlewares := make([]any, 0, len(rules))
+
+	for _, rule := range rules {
+		middleware := &unstructured.Unstructured{}
+		middleware.SetGroupVersionKind(TraefikMiddlewareGVK)
+		middleware.SetName(rule.Name)
+		middleware.SetNamespace(redirect.Namespace)
+		middleware.SetLabels(GetLabelsForRedirect(redirect.Name))
+
+		// redirectRegex matches the whole URL including the query, which the rest of the path of
+		// prefix rules captures as well. Traefik references capture groups as ${1}
+		middleware.Object["spec"] = map[string]any{
+			"redirectRegex": map[string]any{
+				"regex":       fmt.Sprintf("^https?://(?:%s)(?::\\d+)?%s", strings.Join(quotedHosts, "|"), strings.TrimPrefix(rule.Regex, "^")),
+				"replacement": traefikCaptureGroup.ReplaceAllString(rule.Replacement(), "$${$1}"),
+				"permanent":   isPermanent(rule.Code),
+			},
+		}
+
+		objects = append(objects, middleware)
+		middlewares = append(middlewares, map[string]any{"name": rule.Name})
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(TraefikIngressRouteGVK)
+	route.SetName(redirect.Name)
+	route.SetNamespace(redirect.Namespace)
+	route.SetLabels(GetLabelsForRedirect(redirect.Name))
+
+	spec := map[string]any{
+		"routes": []any{
+			map[string]any{
+				"kind":        "Rule",
+				"match":       strings.Join(matchHosts, " || "),
+				"middlewares": middlewares,
+				"services": []any{
+					map[string]any{"name": "noop@internal", "kind": "TraefikService"},
+				},
+			},
+		},
+	}
+
+	if redirect.Spec.TLS.Enable {
+		spec["tls"] = map[string]any{
+			"secretName": tlsSecretName(redirect),
+		}
+
+		route.SetAnnotations(redirect.Spec.TLS.Annotations)
+	}
+
+	route.Object["spec"] = spec
+
+	return append(objects, route), nil
+}
+
+func (r *TraefikRenderer) Types() []client.Object {
+	middleware := &unstructured.Unstructured{}
+	middleware.SetGroupVersionKind(TraefikMiddlewareGVK)
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(TraefikIngressRouteGVK)
+
+	return []client.Object{middleware, route}
+}
+
+// traefikCaptureGroup matches references to capture groups like $1, which Traefik expects as ${1}
+var traefikCaptureGroup = regexp.MustCompile(`\$(\d)`)
+
+// isPermanent returns true if code is a permanent redirect status code
+func isPermanent(code int) bool {
+	return code == 301 || code == 308
+}
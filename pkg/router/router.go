@@ -33,11 +33,15 @@ import (
 // @in header
 // @name Authorization
 
-func NewGinGonicHTTPServer(bindAddr string, serviceName string) (*gin.Engine, *http.Server) {
+func NewGinGonicHTTPServer(bindAddr string, serviceName string, redirectController *urlShortenerController.RedirectController) (*gin.Engine, *http.Server) {
 	router := gin.New()
 	router.Use(
 		otelgin.Middleware(serviceName),
 		PromMiddleware(serviceName),
+		// Native redirects are matched by host and take precedence over all routes, except on the hosts of the
+		// urlshortener itself. They are rate limited like shortlinks and handled before the SSL redirect,
+		// as the source host of a redirect without TLS has no certificate
+		redirectController.HandleNativeRedirect,
 		secure.Secure(secure.Options{
 			SSLRedirect:           true,
 			SSLProxyHeaders:       map[string]string{"X-Forwarded-Proto": "https"},
@@ -69,17 +73,47 @@ func NewGinGonicHTTPServer(bindAddr string, serviceName string) (*gin.Engine, *h
 	return router, srv
 }
 
-func Load(router *gin.Engine, shortlinkController *urlShortenerController.ShortlinkController) {
+func Load(router *gin.Engine, shortlinkController *urlShortenerController.ShortlinkController, rateLimiter *urlShortenerController.RateLimiter) {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	router.GET("/:shortlink", shortlinkController.HandleShortLink)
+	router.GET("/:shortlink", rateLimiter.LimitRedirect, shortlinkController.HandleShortLink)
 
 	{
-		v1 := router.Group("/api/v1")
+		v1 := router.Group("/api/v1", rateLimiter.LimitAPI)
 		v1.GET("/shortlink/", shortlinkController.HandleListShortLink)
 		v1.GET("/shortlink/:shortlink", shortlinkController.HandleGetShortLink)
 		v1.POST("/shortlink/:shortlink", shortlinkController.HandleCreateShortLink)
 		v1.PUT("/shortlink/:shortlink", shortlinkController.HandleUpdateShortLink)
+		v1.PATCH("/shortlink/:shortlink", shortlinkController.HandlePatchShortLink)
 		v1.DELETE("/shortlink/:shortlink", shortlinkController.HandleDeleteShortLink)
+		v1.GET("/shortlink/:shortlink/history", shortlinkController.HandleGetShortLinkHistory)
+		v1.GET("/shortlink/:shortlink/revisions", shortlinkController.HandleListShortLinkRevisions)
+		v1.POST("/shortlink/:shortlink/rollback", shortlinkController.HandleRollbackShortLink)
+	}
+}
+
+// LoadUI registers the routes of the web UI under /ui
+func LoadUI(router *gin.Engine, uiController *urlShortenerController.UIController, rateLimiter *urlShortenerController.RateLimiter) {
+	// /ui would otherwise be matched as a shortlink
+	router.GET("/ui", func(ct *gin.Context) {
+		ct.Redirect(http.StatusMovedPermanently, "/ui/")
+	})
+
+	ui := router.Group("/ui", rateLimiter.LimitAPI)
+	ui.GET("/login", uiController.HandleUILoginPage)
+	ui.GET("/login/oauth", uiController.HandleUILogin)
+	ui.GET("/callback", uiController.HandleUICallback)
+
+	{
+		authenticated := ui.Group("", uiController.RequireLogin, rateLimiter.LimitUIUser)
+		authenticated.GET("/", uiController.HandleUIList)
+		authenticated.GET("/new", uiController.HandleUINew)
+		authenticated.POST("/new", uiController.HandleUICreate)
+		authenticated.GET("/links/:shortlink", uiController.HandleUIEdit)
+		authenticated.POST("/links/:shortlink", uiController.HandleUIUpdate)
+		authenticated.POST("/links/:shortlink/delete", uiController.HandleUIDelete)
+		authenticated.POST("/links/:shortlink/owners", uiController.HandleUIOwners)
+		authenticated.GET("/links/:shortlink/qr.png", uiController.HandleUIQRCode)
+		authenticated.POST("/logout", uiController.HandleUILogout)
 	}
 }
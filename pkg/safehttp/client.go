@@ -0,0 +1,105 @@
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// MaxRedirects is the number of redirects a Client follows before giving up
+const MaxRedirects = 5
+
+// ErrTooManyRedirects is returned if a target redirects more than MaxRedirects times
+var ErrTooManyRedirects = errors.New("stopped after too many redirects")
+
+// ForbiddenAddressError is returned if a target resolves to an address which isn't publicly routable
+type ForbiddenAddressError struct {
+	Address string
+}
+
+func (e *ForbiddenAddressError) Error() string {
+	return fmt.Sprintf("address %s is not publicly routable", e.Address)
+}
+
+// nonPublic are the ranges which aren't covered by the helpers of netip but mustn't be reached either
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// NewClient returns a http.Client for requesting user supplied URLs. It only connects to publicly routable
+// addresses, which are checked after DNS resolution so that neither hostnames nor redirects can point it at
+// cluster internal services, and follows at most MaxRedirects redirects to http and https URLs
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Proxies are not used, as the proxy would connect to the target on our behalf without the address check
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// IsPublic returns true if addr is a publicly routable unicast address
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// control rejects connections to addresses which aren't publicly routable. It is called with the resolved address
+// of every connection, including the ones made while following redirects
+func control(_ string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return &ForbiddenAddressError{Address: address}
+	}
+
+	if !IsPublic(addrPort.Addr()) {
+		return &ForbiddenAddressError{Address: addrPort.Addr().String()}
+	}
+
+	return nil
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > MaxRedirects {
+		return ErrTooManyRedirects
+	}
+
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package safehttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := map[string]bool{
+		"1.1.1.1":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"fc00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+		"224.0.0.1":       false,
+	}
+
+	for addr, public := range tests {
+		if got := IsPublic(netip.MustParseAddr(addr)); got != public {
+			t.Errorf("IsPublic(%s) = %v, want %v", addr, got, public)
+		}
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the server must not be reached")
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Get(server.URL)
+
+	var forbidden *ForbiddenAddressError
+	if !errors.As(err, &forbidden) {
+		t.Fatalf("expected a ForbiddenAddressError, got %v", err)
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+
+	if err := checkRedirect(req, make([]*http.Request, MaxRedirects)); err != nil {
+		t.Errorf("expected %d redirects to be followed, got %v", MaxRedirects, err)
+	}
+
+	if err := checkRedirect(req, make([]*http.Request, MaxRedirects+1)); !errors.Is(err, ErrTooManyRedirects) {
+		t.Errorf("expected ErrTooManyRedirects, got %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "ftp://example.com/", nil)
+	if err := checkRedirect(req, nil); err == nil {
+		t.Error("expected a redirect to ftp to be refused")
+	}
+}
@@ -0,0 +1,69 @@
+package slug
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// MaxLength is the maximum length of a slug in bytes
+const MaxLength = 253
+
+// DefaultReserved are the slugs which collide with the routes of the urlshortener
+var DefaultReserved = []string{"api", "assets", "swagger", "ui"}
+
+// Normalize returns the case-insensitive form of slug, under which it is looked up
+func Normalize(slug string) string {
+	return strings.ToLower(slug)
+}
+
+// ObjectName returns the name of the ShortLink serving slug. Slugs which are valid object names are used as is,
+// all others are mapped to a name derived from the hash of the normalized slug
+func ObjectName(slug string) string {
+	normalized := Normalize(slug)
+
+	if len(validation.IsDNS1123Subdomain(normalized)) == 0 {
+		return normalized
+	}
+
+	hash := sha256.Sum256([]byte(normalized))
+	return "slug-" + hex.EncodeToString(hash[:])[:20]
+}
+
+// Validate returns an error if slug can't be served as a single path segment
+func Validate(slug string) error {
+	switch {
+	case slug == "":
+		return fmt.Errorf("slug must not be empty")
+	case len(slug) > MaxLength:
+		return fmt.Errorf("slug must be no more than %d bytes", MaxLength)
+	case !utf8.ValidString(slug):
+		return fmt.Errorf("slug %q is not valid UTF-8", slug)
+	case slug == "." || slug == "..":
+		return fmt.Errorf("slug %q is not allowed", slug)
+	}
+
+	for _, r := range slug {
+		if r == '/' || r == '?' || r == '#' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("slug %q must not contain %q", slug, r)
+		}
+	}
+
+	return nil
+}
+
+// IsReserved reports whether slug is one of the reserved slugs, ignoring case
+func IsReserved(slug string, reserved []string) bool {
+	for _, name := range reserved {
+		if Normalize(name) == Normalize(slug) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package slug
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Variants returns the normalized slugs which are tried if slug doesn't match a ShortLink:
+// slug without trailing punctuation, and with dashes and underscores swapped
+func Variants(slug string) []string {
+	normalized := Normalize(slug)
+	trimmed := strings.TrimRightFunc(normalized, unicode.IsPunct)
+
+	variants := make([]string, 0)
+	seen := map[string]bool{normalized: true, "": true}
+
+	for _, variant := range []string{
+		trimmed,
+		strings.ReplaceAll(normalized, "_", "-"),
+		strings.ReplaceAll(normalized, "-", "_"),
+		strings.ReplaceAll(trimmed, "_", "-"),
+		strings.ReplaceAll(trimmed, "-", "_"),
+	} {
+		if !seen[variant] {
+			seen[variant] = true
+			variants = append(variants, variant)
+		}
+	}
+
+	return variants
+}
+
+// Suggest returns up to max of the slugs closest to slug by edit distance, ignoring case.
+// Only slugs within a distance of a third of the length of slug are suggested, but at least 1 and at most 3
+func Suggest(slug string, slugs []string, max int) []string {
+	normalized := []rune(Normalize(slug))
+
+	threshold := len(normalized) / 3
+	if threshold < 1 {
+		threshold = 1
+	} else if threshold > 3 {
+		threshold = 3
+	}
+
+	type suggestion struct {
+		slug     string
+		distance int
+	}
+
+	suggestions := make([]suggestion, 0)
+	seen := make(map[string]bool)
+
+	for _, candidate := range slugs {
+		if seen[Normalize(candidate)] {
+			continue
+		}
+		seen[Normalize(candidate)] = true
+
+		if distance := editDistance(normalized, []rune(Normalize(candidate))); distance <= threshold {
+			suggestions = append(suggestions, suggestion{slug: candidate, distance: distance})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+
+		return suggestions[i].slug < suggestions[j].slug
+	})
+
+	result := make([]string, 0, max)
+	for i := 0; i < len(suggestions) && i < max; i++ {
+		result = append(result, suggestions[i].slug)
+	}
+
+	return result
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
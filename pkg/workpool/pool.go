@@ -0,0 +1,119 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+)
+
+// Work is run by the workers of a Pool for a target, e.g. probing or fetching it
+type Work[T any] func(ctx context.Context, target string) (T, error)
+
+// Pool runs work on a bounded number of workers, so that callers, e.g. reconcilers, don't wait for slow targets.
+// Work is submitted under a key, which is passed to the done callback once the result is available
+type Pool[T any] struct {
+	work    Work[T]
+	workers int
+	done    func(key string)
+
+	jobs chan job
+
+	mu      sync.Mutex
+	pending map[string]bool
+	results map[string]outcome[T]
+}
+
+type job struct {
+	key    string
+	target string
+}
+
+type outcome[T any] struct {
+	target string
+	result T
+	err    error
+}
+
+// New creates a new Pool running work on workers workers. At most queueSize targets wait for a worker, further
+// targets are refused. done is called with the key of a target once its result is available
+func New[T any](work Work[T], workers int, queueSize int, done func(key string)) *Pool[T] {
+	return &Pool[T]{
+		work:    work,
+		workers: workers,
+		done:    done,
+		jobs:    make(chan job, queueSize),
+		pending: make(map[string]bool),
+		results: make(map[string]outcome[T]),
+	}
+}
+
+// Start runs the workers until ctx is done. It implements manager.Runnable
+func (p *Pool[T]) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.run(ctx)
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (p *Pool[T]) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-p.jobs:
+			result, err := p.work(ctx, job.target)
+
+			p.mu.Lock()
+			delete(p.pending, job.key)
+			p.results[job.key] = outcome[T]{target: job.target, result: result, err: err}
+			p.mu.Unlock()
+
+			p.done(job.key)
+		}
+	}
+}
+
+// Submit queues target under key. It returns false if work for key is already queued or running,
+// or if the queue is full
+func (p *Pool[T]) Submit(key string, target string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending[key] {
+		return false
+	}
+
+	select {
+	case p.jobs <- job{key: key, target: target}:
+		p.pending[key] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// Result returns the result of the finished work on target under key and forgets it.
+// ok is false if there is no such result, e.g. as the work is still running or was done for another target
+func (p *Pool[T]) Result(key string, target string) (result T, err error, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	outcome, found := p.results[key]
+	if !found {
+		return result, nil, false
+	}
+
+	delete(p.results, key)
+	if outcome.target != target {
+		return result, nil, false
+	}
+
+	return outcome.result, outcome.err, true
+}
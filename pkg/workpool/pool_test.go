@@ -0,0 +1,104 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	release := make(chan struct{})
+	work := func(ctx context.Context, target string) (string, error) {
+		<-release
+		return "checked " + target, nil
+	}
+
+	done := make(chan string, 10)
+	pool := New[string](work, 1, 1, func(key string) {
+		done <- key
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = pool.Start(ctx)
+	}()
+
+	if !pool.Submit("default/a", "https://example.com") {
+		t.Fatal("Submit() refused the first target")
+	}
+
+	if pool.Submit("default/a", "https://example.com") {
+		t.Error("Submit() accepted a target which is already pending")
+	}
+
+	if _, _, ok := pool.Result("default/a", "https://example.com"); ok {
+		t.Error("Result() returned the result of pending work")
+	}
+
+	close(release)
+
+	select {
+	case key := <-done:
+		if key != "default/a" {
+			t.Errorf("done(%q), want default/a", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the work didn't finish")
+	}
+
+	result, err, ok := pool.Result("default/a", "https://example.com")
+	if !ok || err != nil || result != "checked https://example.com" {
+		t.Fatalf("Result() = %q, %v, %t, want the result of the work", result, err, ok)
+	}
+
+	if _, _, ok := pool.Result("default/a", "https://example.com"); ok {
+		t.Error("Result() returned a result twice")
+	}
+}
+
+func TestPoolReportsErrors(t *testing.T) {
+	failed := errors.New("failed")
+	done := make(chan string, 1)
+	pool := New[string](func(context.Context, string) (string, error) { return "", failed }, 1, 1, func(key string) {
+		done <- key
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = pool.Start(ctx)
+	}()
+
+	pool.Submit("default/a", "https://example.com")
+	<-done
+
+	if _, err, ok := pool.Result("default/a", "https://example.com"); !ok || !errors.Is(err, failed) {
+		t.Errorf("Result() = %v, %t, want the error of the work", err, ok)
+	}
+}
+
+func TestPoolRefusesWhenQueueIsFull(t *testing.T) {
+	// Without started workers, the queue of a single target fills up
+	pool := New[string](func(context.Context, string) (string, error) { return "", nil }, 1, 1, func(string) {})
+
+	if !pool.Submit("default/a", "https://example.com") {
+		t.Fatal("Submit() refused the first target")
+	}
+
+	if pool.Submit("default/b", "https://example.com") {
+		t.Error("Submit() accepted a target exceeding the queue")
+	}
+}
+
+func TestPoolResultOfChangedTarget(t *testing.T) {
+	pool := New[string](func(context.Context, string) (string, error) { return "", nil }, 1, 1, func(string) {})
+	pool.results["default/a"] = outcome[string]{target: "https://old.example.com", result: "old"}
+
+	if _, _, ok := pool.Result("default/a", "https://new.example.com"); ok {
+		t.Error("Result() returned the result of the previous target")
+	}
+}